@@ -0,0 +1,467 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+)
+
+var ErrBadWebhook = errors.New("webhook is invalid")
+var ErrNoWebhook = errors.New("webhook does not exist")
+var ErrTooManyWebhooks = errors.New("too many webhooks")
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// BaseFee is the tier of webhooks watching the next base fee.
+const BaseFee = "baseFee"
+
+const (
+	Below = "below"
+	Above = "above"
+)
+
+const maxAttempts = 4
+const firstRetryDelay = time.Second
+const deliveryTimeout = 10 * time.Second
+const maxWebhooks = 20
+const deliveryWorkers = 8
+const deliveryQueue = 256
+
+// Tolerance is how far the timestamp of a call may be from the clock of the
+// receiver before Verify rejects it as a replay.
+const Tolerance = 5 * time.Minute
+
+// Webhook is called when the price of its tier goes below or above the
+// threshold, at most once per cooldown. The secret signs the payloads and is
+// only reported when the webhook is registered. The owner is the name of the
+// API key that registered it.
+type Webhook struct {
+	ID        string `json:"id"`
+	Owner     string `json:"owner"`
+	URL       string `json:"url"`
+	Tier      string `json:"tier"`
+	Condition string `json:"condition"`
+	Threshold string `json:"threshold"`
+	Cooldown  uint64 `json:"cooldown"`
+	Secret    string `json:"secret,omitempty"`
+}
+
+type Subscriber interface {
+	Subscribe() (<-chan gasprice.Estimate, func())
+}
+
+type Registry struct {
+	path       string
+	names      []string
+	webhooks   map[string]Webhook
+	lastFired  map[string]time.Time
+	client     *http.Client
+	retryDelay time.Duration
+	lock       sync.Mutex
+}
+
+// NewRegistry loads the webhooks persisted at path, if any. The names are
+// the tiers of the estimates in order.
+func NewRegistry(path string, names []string) (*Registry, error) {
+	r := &Registry{
+		path,
+		names,
+		make(map[string]Webhook),
+		make(map[string]time.Time),
+		newClient(),
+		firstRetryDelay,
+		sync.Mutex{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []Webhook
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		r.webhooks[webhook.ID] = webhook
+	}
+	return r, nil
+}
+
+func randomHex() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var reservedNetworks = []*net.IPNet{
+	// this network, shared address space and benchmarking
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+	{IP: net.IPv4(198, 18, 0, 0), Mask: net.CIDRMask(15, 32)},
+}
+
+// public reports whether the webhooks may call the address, which is not
+// the case of loopback, private, link-local, and so cloud metadata, and
+// other reserved addresses.
+func public(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialPublic refuses connections to addresses that are not public. It runs
+// on every connection after the host is resolved, so redirects and hosts
+// resolving to another address than at registration are checked too.
+func dialPublic(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !public(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: deliveryTimeout, Control: dialPublic}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: deliveryTimeout,
+		MaxIdleConnsPerHost: 1,
+	}
+	return &http.Client{Transport: transport, Timeout: deliveryTimeout}
+}
+
+// checkHost rejects hosts resolving to an address that is not public.
+func checkHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return ErrBadWebhook
+	}
+	for _, addr := range addrs {
+		if !public(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+func (r *Registry) validate(ctx context.Context, webhook Webhook) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrBadWebhook
+	}
+	if webhook.Condition != Below && webhook.Condition != Above {
+		return ErrBadWebhook
+	}
+	if threshold, ok := new(big.Int).SetString(webhook.Threshold, 10); !ok || threshold.Sign() < 0 {
+		return ErrBadWebhook
+	}
+	if webhook.Tier != BaseFee && !slices.Contains(r.names, webhook.Tier) {
+		return ErrBadWebhook
+	}
+	return checkHost(ctx, u.Hostname())
+}
+
+// save writes the webhooks to a temporary file and moves it over the
+// persisted one. It must be called with the lock held.
+func (r *Registry) save() error {
+	webhooks := make([]Webhook, 0, len(r.webhooks))
+	for _, webhook := range r.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	data, err := json.MarshalIndent(webhooks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// Add registers the webhook of the owner with a new ID, and a new secret if
+// it has none, unless the owner already has too many.
+func (r *Registry) Add(ctx context.Context, owner string, webhook Webhook) (Webhook, error) {
+	if err := r.validate(ctx, webhook); err != nil {
+		return Webhook{}, err
+	}
+	id, err := randomHex()
+	if err != nil {
+		return Webhook{}, err
+	}
+	webhook.ID = id
+	webhook.Owner = owner
+	if webhook.Secret == "" {
+		if webhook.Secret, err = randomHex(); err != nil {
+			return Webhook{}, err
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.owned(owner)) >= maxWebhooks {
+		return Webhook{}, ErrTooManyWebhooks
+	}
+	r.webhooks[id] = webhook
+	if err := r.save(); err != nil {
+		delete(r.webhooks, id)
+		return Webhook{}, err
+	}
+	return webhook, nil
+}
+
+// owned returns the webhooks of the owner ordered by ID. It must be called
+// with the lock held.
+func (r *Registry) owned(owner string) []Webhook {
+	webhooks := make([]Webhook, 0)
+	for _, webhook := range r.webhooks {
+		if webhook.Owner == owner {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks
+}
+
+// List returns the webhooks of the owner without their secrets.
+func (r *Registry) List(owner string) []Webhook {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	webhooks := r.owned(owner)
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks
+}
+
+// Remove deletes the webhook of the owner with the id. The webhooks of other
+// owners are reported as missing.
+func (r *Registry) Remove(owner string, id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok || webhook.Owner != owner {
+		return ErrNoWebhook
+	}
+	delete(r.webhooks, id)
+	if err := r.save(); err != nil {
+		r.webhooks[id] = webhook
+		return err
+	}
+	delete(r.lastFired, id)
+	return nil
+}
+
+// Run checks every new estimate of the subscriber against the webhooks and
+// calls the ones whose conditions are met until the context is done. The
+// calls are made by a fixed number of workers, and dropped when too many
+// are waiting for them.
+func (r *Registry) Run(ctx context.Context, subscriber Subscriber) {
+	ch, unsubscribe := subscriber.Subscribe()
+	defer unsubscribe()
+
+	queue := make(chan trigger, deliveryQueue)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(queue)
+	for i := 0; i < deliveryWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				r.deliver(ctx, t.webhook, t.payload)
+			}
+		}()
+	}
+
+	for {
+		select {
+		case estimate := <-ch:
+			for _, t := range r.triggered(estimate, time.Now()) {
+				select {
+				case queue <- t:
+				default:
+					slog.WarnContext(ctx, "could not queue webhook call", "webhook", t.webhook.ID)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type blockPayload struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
+	Time   uint64 `json:"timestamp"`
+}
+
+type payload struct {
+	ID        string       `json:"id"`
+	Tier      string       `json:"tier"`
+	Condition string       `json:"condition"`
+	Threshold string       `json:"threshold"`
+	Value     string       `json:"value"`
+	Block     blockPayload `json:"block"`
+	Timestamp int64        `json:"timestamp"`
+}
+
+type trigger struct {
+	webhook Webhook
+	payload payload
+}
+
+func (r *Registry) value(estimate gasprice.Estimate, tier string) *big.Int {
+	if tier == BaseFee {
+		return estimate.NextBaseFee
+	}
+	for i, name := range r.names {
+		if name == tier && i < len(estimate.Prices) {
+			return estimate.Prices[i]
+		}
+	}
+	return nil
+}
+
+// triggered returns the webhooks whose conditions are met by the estimate
+// and are out of their cooldown, and marks them as fired.
+func (r *Registry) triggered(estimate gasprice.Estimate, now time.Time) []trigger {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	triggers := make([]trigger, 0)
+	for id, webhook := range r.webhooks {
+		value := r.value(estimate, webhook.Tier)
+		if value == nil {
+			continue
+		}
+		threshold, _ := new(big.Int).SetString(webhook.Threshold, 10)
+		cmp := value.Cmp(threshold)
+		if (webhook.Condition == Below && cmp >= 0) || (webhook.Condition == Above && cmp <= 0) {
+			continue
+		}
+		cooldown := time.Duration(webhook.Cooldown) * time.Second
+		if last, ok := r.lastFired[id]; ok && now.Sub(last) < cooldown {
+			continue
+		}
+
+		r.lastFired[id] = now
+		triggers = append(triggers, trigger{webhook, payload{
+			id,
+			webhook.Tier,
+			webhook.Condition,
+			webhook.Threshold,
+			value.String(),
+			blockPayload{estimate.Block.Number, estimate.Block.Hash.Hex(), estimate.Block.Time},
+			0,
+		}})
+	}
+	return triggers
+}
+
+// Sign returns the signature sent in the X-Signature header of a payload,
+// the hex encoded HMAC-SHA256 keyed by the webhook secret of the timestamp
+// sent in the X-Timestamp header, a dot and the body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature and timestamp headers of a call are
+// those of the body, and the timestamp is within the tolerance of now so a
+// captured call can not be replayed later.
+func Verify(secret string, timestamp string, signature string, body []byte, now time.Time) bool {
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(t, 0)); d > Tolerance || d < -Tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, t, body)))
+}
+
+// post sends the payload stamped with the current time, so every attempt
+// carries a fresh timestamp.
+func (r *Registry) post(ctx context.Context, webhook Webhook, p payload) error {
+	p.Timestamp = time.Now().Unix()
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", webhook.ID)
+	req.Header.Set("X-Timestamp", strconv.FormatInt(p.Timestamp, 10))
+	req.Header.Set("X-Signature", Sign(webhook.Secret, p.Timestamp, body))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// deliver posts the payload, retrying with an exponential backoff.
+func (r *Registry) deliver(ctx context.Context, webhook Webhook, p payload) {
+	delay := r.retryDelay
+	for attempt := 1; ; attempt++ {
+		err := r.post(ctx, webhook, p)
+		if err == nil {
+			return
+		}
+		if attempt == maxAttempts {
+			slog.ErrorContext(ctx, "could not call webhook", "webhook", webhook.ID, "attempts", attempt, "err", err)
+			return
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+)
+
+var names = []string{"low", "high"}
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	registry, err := NewRegistry(path, names)
+	if err != nil {
+		t.Fatal("could not create registry:", err)
+	}
+	ctx := context.Background()
+
+	invalid := []Webhook{
+		{URL: "ftp://1.1.1.1", Tier: "low", Condition: Below, Threshold: "10"},
+		{URL: "https://1.1.1.1/hook", Tier: "medium", Condition: Below, Threshold: "10"},
+		{URL: "https://1.1.1.1/hook", Tier: "low", Condition: "equal", Threshold: "10"},
+		{URL: "https://1.1.1.1/hook", Tier: "low", Condition: Below, Threshold: "-10"},
+	}
+	for i, webhook := range invalid {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			if _, err := registry.Add(ctx, "partner", webhook); err != ErrBadWebhook {
+				t.Error("Add accepted an invalid webhook")
+			}
+		})
+	}
+
+	forbidden := []string{
+		"http://127.0.0.1:8080/admin",
+		"http://localhost/hook",
+		"http://10.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fd00:ec2::254]/hook",
+	}
+	for i, u := range forbidden {
+		t.Run("forbidden"+strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			webhook := Webhook{URL: u, Tier: "low", Condition: Below, Threshold: "10"}
+			if _, err := registry.Add(ctx, "partner", webhook); err != ErrForbiddenAddress {
+				t.Errorf("Add returned %v for %s", err, u)
+			}
+		})
+	}
+
+	webhook, err := registry.Add(ctx, "partner", Webhook{URL: "https://1.1.1.1/hook", Tier: BaseFee, Condition: Above, Threshold: "10", Owner: "other"})
+	if err != nil {
+		t.Fatal("Add returned error:", err)
+	}
+	if webhook.ID == "" || webhook.Secret == "" || webhook.Owner != "partner" {
+		t.Fatal("Add did not generate an id and a secret or set the owner")
+	}
+
+	reloaded, err := NewRegistry(path, names)
+	if err != nil {
+		t.Fatal("could not reload registry:", err)
+	}
+	if list := reloaded.List("other"); len(list) != 0 {
+		t.Fatalf("the webhooks of partner are listed for other: %v", list)
+	}
+	list := reloaded.List("partner")
+	if len(list) != 1 || list[0].ID != webhook.ID || list[0].Secret != "" {
+		t.Fatalf("reloaded registry has wrong webhooks: %v", list)
+	}
+	if reloaded.webhooks[webhook.ID].Secret != webhook.Secret {
+		t.Fatal("reloaded registry lost the secret")
+	}
+
+	if err := reloaded.Remove("other", webhook.ID); err != ErrNoWebhook {
+		t.Fatal("Remove deleted the webhook of another owner")
+	}
+	if err := reloaded.Remove("partner", webhook.ID); err != nil {
+		t.Fatal("Remove returned error:", err)
+	}
+	if err := reloaded.Remove("partner", webhook.ID); err != ErrNoWebhook {
+		t.Fatal("Remove did not report a missing webhook")
+	}
+}
+
+func TestRegistryTriggered(t *testing.T) {
+	registry, err := NewRegistry(filepath.Join(t.TempDir(), "webhooks.json"), names)
+	if err != nil {
+		t.Fatal("could not create registry:", err)
+	}
+	ctx := context.Background()
+	low, _ := registry.Add(ctx, "partner", Webhook{URL: "https://1.1.1.1/hook", Tier: "low", Condition: Below, Threshold: "20", Cooldown: 60})
+	registry.Add(ctx, "partner", Webhook{URL: "https://1.1.1.1/hook", Tier: BaseFee, Condition: Above, Threshold: "100"})
+
+	estimate := gasprice.Estimate{
+		Prices:      []*big.Int{big.NewInt(10), big.NewInt(30)},
+		NextBaseFee: big.NewInt(50),
+	}
+	now := time.Unix(1000, 0)
+	triggers := registry.triggered(estimate, now)
+	if len(triggers) != 1 || triggers[0].webhook.ID != low.ID || triggers[0].payload.Value != "10" {
+		t.Fatalf("triggered returned wrong webhooks: %v", triggers)
+	}
+	if len(registry.triggered(estimate, now.Add(30*time.Second))) != 0 {
+		t.Fatal("triggered ignored the cooldown")
+	}
+	if len(registry.triggered(estimate, now.Add(60*time.Second))) != 1 {
+		t.Fatal("triggered did not fire after the cooldown")
+	}
+}
+
+func TestRegistryDeliver(t *testing.T) {
+	var calls int32
+	var signed atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signed.Store(Verify("secret", r.Header.Get("X-Timestamp"), r.Header.Get("X-Signature"), body, time.Now()))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var p payload
+		json.Unmarshal(body, &p)
+		if p.Value != "10" || strconv.FormatInt(p.Timestamp, 10) != r.Header.Get("X-Timestamp") {
+			t.Error("webhook received wrong payload")
+		}
+	}))
+	defer server.Close()
+
+	registry, err := NewRegistry(filepath.Join(t.TempDir(), "webhooks.json"), names)
+	if err != nil {
+		t.Fatal("could not create registry:", err)
+	}
+	registry.retryDelay = time.Millisecond
+	registry.client = server.Client()
+	webhook := Webhook{ID: "id", URL: server.URL, Secret: "secret"}
+	registry.deliver(context.Background(), webhook, payload{Value: "10"})
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("webhook was called %d times but expected 3", calls)
+	}
+	if !signed.Load() {
+		t.Fatal("webhook payload was not signed")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"value":"10"}`)
+	now := time.Unix(1000, 0)
+	signature := Sign("secret", 1000, body)
+
+	tests := []struct {
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		expected  bool
+	}{
+		{"secret", "1000", signature, body, now, true},
+		{"secret", "1000", signature, body, now.Add(Tolerance), true},
+		{"secret", "1000", signature, body, now.Add(Tolerance + time.Second), false},
+		{"secret", "1000", signature, body, now.Add(-Tolerance - time.Second), false},
+		{"secret", "1001", signature, body, now, false},
+		{"secret", "", signature, body, now, false},
+		{"other", "1000", signature, body, now, false},
+		{"secret", "1000", signature, []byte(`{"value":"11"}`), now, false},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			if Verify(test.secret, test.timestamp, test.signature, test.body, test.now) != test.expected {
+				t.Errorf("Verify did not return %t", test.expected)
+			}
+		})
+	}
+}
+
+func TestRegistryLimit(t *testing.T) {
+	registry, err := NewRegistry(filepath.Join(t.TempDir(), "webhooks.json"), names)
+	if err != nil {
+		t.Fatal("could not create registry:", err)
+	}
+	ctx := context.Background()
+	webhook := Webhook{URL: "https://1.1.1.1/hook", Tier: "low", Condition: Below, Threshold: "10"}
+	for i := 0; i < maxWebhooks; i++ {
+		if _, err := registry.Add(ctx, "partner", webhook); err != nil {
+			t.Fatal("Add returned error:", err)
+		}
+	}
+	if _, err := registry.Add(ctx, "partner", webhook); err != ErrTooManyWebhooks {
+		t.Fatalf("Add returned %v over the limit", err)
+	}
+	if _, err := registry.Add(ctx, "other", webhook); err != nil {
+		t.Fatal("the limit of partner applied to other:", err)
+	}
+}
+
+func TestClientPublic(t *testing.T) {
+	var calls int32
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer private.Close()
+	if _, err := newClient().Get(private.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("client called a loopback address: %v", err)
+	}
+
+	// the redirecting server is reached directly, and the redirect to the
+	// loopback address is refused by the dialer
+	redirect := httptest.NewServer(http.RedirectHandler(private.URL, http.StatusFound))
+	defer redirect.Close()
+	client := newClient()
+	redirectAddr := redirect.Listener.Addr().String()
+	dialer := &net.Dialer{Control: func(network string, address string, conn syscall.RawConn) error {
+		if address == redirectAddr {
+			return nil
+		}
+		return dialPublic(network, address, conn)
+	}}
+	client.Transport.(*http.Transport).DialContext = dialer.DialContext
+	if _, err := client.Get(redirect.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("client followed a redirect to a loopback address: %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Fatal("private server was called")
+	}
+}
+
+type subscriberMock chan gasprice.Estimate
+
+func (s subscriberMock) Subscribe() (<-chan gasprice.Estimate, func()) {
+	return s, func() {}
+}
+
+func TestRegistryRun(t *testing.T) {
+	called := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called <- r.Header.Get("X-Webhook-ID")
+	}))
+	defer server.Close()
+
+	registry, err := NewRegistry(filepath.Join(t.TempDir(), "webhooks.json"), names)
+	if err != nil {
+		t.Fatal("could not create registry:", err)
+	}
+	registry.client = server.Client()
+	// the test server is on a loopback address, which Add refuses
+	registry.webhooks["id"] = Webhook{ID: "id", URL: server.URL, Tier: "low", Condition: Below, Threshold: "20", Secret: "secret"}
+
+	ch := make(subscriberMock, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		registry.Run(ctx, ch)
+		close(done)
+	}()
+	ch <- gasprice.Estimate{Prices: []*big.Int{big.NewInt(10), big.NewInt(30)}}
+	select {
+	case id := <-called:
+		if id != "id" {
+			t.Errorf("webhook %s was called", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not called")
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,935 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: yaegpe/v1/yaegpe.proto
+
+package yaegpev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Block struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Number    uint64 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Hash      string `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	Timestamp uint64 `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Block) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Block) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Block) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type Tier struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Price string `protobuf:"bytes,2,opt,name=price,proto3" json:"price,omitempty"`
+}
+
+func (x *Tier) Reset() {
+	*x = Tier{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Tier) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tier) ProtoMessage() {}
+
+func (x *Tier) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tier.ProtoReflect.Descriptor instead.
+func (*Tier) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Tier) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tier) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+type GetGasPricesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetGasPricesRequest) Reset() {
+	*x = GetGasPricesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetGasPricesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGasPricesRequest) ProtoMessage() {}
+
+func (x *GetGasPricesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGasPricesRequest.ProtoReflect.Descriptor instead.
+func (*GetGasPricesRequest) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{2}
+}
+
+type WatchGasPricesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *WatchGasPricesRequest) Reset() {
+	*x = WatchGasPricesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchGasPricesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchGasPricesRequest) ProtoMessage() {}
+
+func (x *WatchGasPricesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchGasPricesRequest.ProtoReflect.Descriptor instead.
+func (*WatchGasPricesRequest) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{3}
+}
+
+type GasPrices struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Block  *Block  `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Latest *Block  `protobuf:"bytes,2,opt,name=latest,proto3" json:"latest,omitempty"`
+	Prices []*Tier `protobuf:"bytes,3,rep,name=prices,proto3" json:"prices,omitempty"`
+	// empty on chains without a base fee
+	NextBaseFee string `protobuf:"bytes,4,opt,name=next_base_fee,json=nextBaseFee,proto3" json:"next_base_fee,omitempty"`
+	// empty on chains without blobs
+	BlobBaseFee string  `protobuf:"bytes,5,opt,name=blob_base_fee,json=blobBaseFee,proto3" json:"blob_base_fee,omitempty"`
+	BlobPrices  []*Tier `protobuf:"bytes,6,rep,name=blob_prices,json=blobPrices,proto3" json:"blob_prices,omitempty"`
+}
+
+func (x *GasPrices) Reset() {
+	*x = GasPrices{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GasPrices) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GasPrices) ProtoMessage() {}
+
+func (x *GasPrices) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GasPrices.ProtoReflect.Descriptor instead.
+func (*GasPrices) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GasPrices) GetBlock() *Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *GasPrices) GetLatest() *Block {
+	if x != nil {
+		return x.Latest
+	}
+	return nil
+}
+
+func (x *GasPrices) GetPrices() []*Tier {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+func (x *GasPrices) GetNextBaseFee() string {
+	if x != nil {
+		return x.NextBaseFee
+	}
+	return ""
+}
+
+func (x *GasPrices) GetBlobBaseFee() string {
+	if x != nil {
+		return x.BlobBaseFee
+	}
+	return ""
+}
+
+func (x *GasPrices) GetBlobPrices() []*Tier {
+	if x != nil {
+		return x.BlobPrices
+	}
+	return nil
+}
+
+type GetFeesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetFeesRequest) Reset() {
+	*x = GetFeesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFeesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFeesRequest) ProtoMessage() {}
+
+func (x *GetFeesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFeesRequest.ProtoReflect.Descriptor instead.
+func (*GetFeesRequest) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{5}
+}
+
+type Fee struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name                 string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	MaxFeePerGas         string `protobuf:"bytes,2,opt,name=max_fee_per_gas,json=maxFeePerGas,proto3" json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string `protobuf:"bytes,3,opt,name=max_priority_fee_per_gas,json=maxPriorityFeePerGas,proto3" json:"max_priority_fee_per_gas,omitempty"`
+}
+
+func (x *Fee) Reset() {
+	*x = Fee{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Fee) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fee) ProtoMessage() {}
+
+func (x *Fee) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fee.ProtoReflect.Descriptor instead.
+func (*Fee) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Fee) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Fee) GetMaxFeePerGas() string {
+	if x != nil {
+		return x.MaxFeePerGas
+	}
+	return ""
+}
+
+func (x *Fee) GetMaxPriorityFeePerGas() string {
+	if x != nil {
+		return x.MaxPriorityFeePerGas
+	}
+	return ""
+}
+
+type GetFeesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Block       *Block `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	NextBaseFee string `protobuf:"bytes,2,opt,name=next_base_fee,json=nextBaseFee,proto3" json:"next_base_fee,omitempty"`
+	Fees        []*Fee `protobuf:"bytes,3,rep,name=fees,proto3" json:"fees,omitempty"`
+}
+
+func (x *GetFeesResponse) Reset() {
+	*x = GetFeesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFeesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFeesResponse) ProtoMessage() {}
+
+func (x *GetFeesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFeesResponse.ProtoReflect.Descriptor instead.
+func (*GetFeesResponse) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetFeesResponse) GetBlock() *Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *GetFeesResponse) GetNextBaseFee() string {
+	if x != nil {
+		return x.NextBaseFee
+	}
+	return ""
+}
+
+func (x *GetFeesResponse) GetFees() []*Fee {
+	if x != nil {
+		return x.Fees
+	}
+	return nil
+}
+
+type GetBaseFeeProjectionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// number of blocks to project, between 1 and 64
+	Blocks uint32 `protobuf:"varint,1,opt,name=blocks,proto3" json:"blocks,omitempty"`
+}
+
+func (x *GetBaseFeeProjectionRequest) Reset() {
+	*x = GetBaseFeeProjectionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBaseFeeProjectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBaseFeeProjectionRequest) ProtoMessage() {}
+
+func (x *GetBaseFeeProjectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBaseFeeProjectionRequest.ProtoReflect.Descriptor instead.
+func (*GetBaseFeeProjectionRequest) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetBaseFeeProjectionRequest) GetBlocks() uint32 {
+	if x != nil {
+		return x.Blocks
+	}
+	return 0
+}
+
+type BaseFeeBound struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Number uint64 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Min    string `protobuf:"bytes,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max    string `protobuf:"bytes,3,opt,name=max,proto3" json:"max,omitempty"`
+}
+
+func (x *BaseFeeBound) Reset() {
+	*x = BaseFeeBound{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BaseFeeBound) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BaseFeeBound) ProtoMessage() {}
+
+func (x *BaseFeeBound) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BaseFeeBound.ProtoReflect.Descriptor instead.
+func (*BaseFeeBound) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BaseFeeBound) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *BaseFeeBound) GetMin() string {
+	if x != nil {
+		return x.Min
+	}
+	return ""
+}
+
+func (x *BaseFeeBound) GetMax() string {
+	if x != nil {
+		return x.Max
+	}
+	return ""
+}
+
+type GetBaseFeeProjectionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Block  *Block          `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Bounds []*BaseFeeBound `protobuf:"bytes,2,rep,name=bounds,proto3" json:"bounds,omitempty"`
+}
+
+func (x *GetBaseFeeProjectionResponse) Reset() {
+	*x = GetBaseFeeProjectionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBaseFeeProjectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBaseFeeProjectionResponse) ProtoMessage() {}
+
+func (x *GetBaseFeeProjectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_yaegpe_v1_yaegpe_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBaseFeeProjectionResponse.ProtoReflect.Descriptor instead.
+func (*GetBaseFeeProjectionResponse) Descriptor() ([]byte, []int) {
+	return file_yaegpe_v1_yaegpe_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetBaseFeeProjectionResponse) GetBlock() *Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *GetBaseFeeProjectionResponse) GetBounds() []*BaseFeeBound {
+	if x != nil {
+		return x.Bounds
+	}
+	return nil
+}
+
+var File_yaegpe_v1_yaegpe_proto protoreflect.FileDescriptor
+
+var file_yaegpe_v1_yaegpe_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2f, 0x76, 0x31, 0x2f, 0x79, 0x61, 0x65, 0x67,
+	0x70, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65,
+	0x2e, 0x76, 0x31, 0x22, 0x51, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06,
+	0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6e, 0x75,
+	0x6d, 0x62, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x30, 0x0a, 0x04, 0x54, 0x69, 0x65, 0x72, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x22, 0x15, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x47,
+	0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x17, 0x0a, 0x15, 0x57, 0x61, 0x74, 0x63, 0x68, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x80, 0x02, 0x0a, 0x09, 0x47, 0x61, 0x73,
+	0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x28,
+	0x0a, 0x06, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x52, 0x06, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x06, 0x70, 0x72, 0x69, 0x63,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x79, 0x61, 0x65, 0x67, 0x70,
+	0x65, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69, 0x65, 0x72, 0x52, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x73, 0x12, 0x22, 0x0a, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x66,
+	0x65, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x65, 0x78, 0x74, 0x42, 0x61,
+	0x73, 0x65, 0x46, 0x65, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x62, 0x5f, 0x62, 0x61,
+	0x73, 0x65, 0x5f, 0x66, 0x65, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x6c,
+	0x6f, 0x62, 0x42, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x12, 0x30, 0x0a, 0x0b, 0x62, 0x6c, 0x6f,
+	0x62, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x69, 0x65, 0x72, 0x52,
+	0x0a, 0x62, 0x6c, 0x6f, 0x62, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x22, 0x10, 0x0a, 0x0e, 0x47,
+	0x65, 0x74, 0x46, 0x65, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x78, 0x0a,
+	0x03, 0x46, 0x65, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x0f, 0x6d, 0x61, 0x78, 0x5f,
+	0x66, 0x65, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x67, 0x61, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73, 0x12,
+	0x36, 0x0a, 0x18, 0x6d, 0x61, 0x78, 0x5f, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x5f,
+	0x66, 0x65, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x67, 0x61, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x14, 0x6d, 0x61, 0x78, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x46, 0x65,
+	0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73, 0x22, 0x81, 0x01, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x46,
+	0x65, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x79, 0x61, 0x65,
+	0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x12, 0x22, 0x0a, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x62, 0x61, 0x73, 0x65,
+	0x5f, 0x66, 0x65, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x65, 0x78, 0x74,
+	0x42, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x12, 0x22, 0x0a, 0x04, 0x66, 0x65, 0x65, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76,
+	0x31, 0x2e, 0x46, 0x65, 0x65, 0x52, 0x04, 0x66, 0x65, 0x65, 0x73, 0x22, 0x35, 0x0a, 0x1b, 0x47,
+	0x65, 0x74, 0x42, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x73, 0x22, 0x4a, 0x0a, 0x0c, 0x42, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x42, 0x6f, 0x75,
+	0x6e, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x69,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x69, 0x6e, 0x12, 0x10, 0x0a, 0x03,
+	0x6d, 0x61, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x78, 0x22, 0x77,
+	0x0a, 0x1c, 0x47, 0x65, 0x74, 0x42, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x50, 0x72, 0x6f, 0x6a,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26,
+	0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e,
+	0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52,
+	0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x2f, 0x0a, 0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e,
+	0x76, 0x31, 0x2e, 0x42, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x42, 0x6f, 0x75, 0x6e, 0x64, 0x52,
+	0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x32, 0xce, 0x02, 0x0a, 0x0f, 0x47, 0x61, 0x73, 0x50,
+	0x72, 0x69, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x44, 0x0a, 0x0c, 0x47,
+	0x65, 0x74, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x1e, 0x2e, 0x79, 0x61,
+	0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x47, 0x61, 0x73, 0x50, 0x72,
+	0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x79, 0x61,
+	0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x73, 0x12, 0x40, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x46, 0x65, 0x65, 0x73, 0x12, 0x19, 0x2e, 0x79,
+	0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x65, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x67, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x42, 0x61, 0x73, 0x65, 0x46, 0x65,
+	0x65, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x26, 0x2e, 0x79, 0x61,
+	0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x61, 0x73, 0x65, 0x46,
+	0x65, 0x65, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x74, 0x42, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4a, 0x0a, 0x0e,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x20,
+	0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68,
+	0x47, 0x61, 0x73, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x14, 0x2e, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x61, 0x73,
+	0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x30, 0x01, 0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x41, 0x72, 0x6d, 0x61, 0x6e, 0x4d, 0x61, 0x7a, 0x64,
+	0x61, 0x65, 0x65, 0x2f, 0x79, 0x61, 0x65, 0x67, 0x70, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x79,
+	0x61, 0x65, 0x67, 0x70, 0x65, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_yaegpe_v1_yaegpe_proto_rawDescOnce sync.Once
+	file_yaegpe_v1_yaegpe_proto_rawDescData = file_yaegpe_v1_yaegpe_proto_rawDesc
+)
+
+func file_yaegpe_v1_yaegpe_proto_rawDescGZIP() []byte {
+	file_yaegpe_v1_yaegpe_proto_rawDescOnce.Do(func() {
+		file_yaegpe_v1_yaegpe_proto_rawDescData = protoimpl.X.CompressGZIP(file_yaegpe_v1_yaegpe_proto_rawDescData)
+	})
+	return file_yaegpe_v1_yaegpe_proto_rawDescData
+}
+
+var file_yaegpe_v1_yaegpe_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_yaegpe_v1_yaegpe_proto_goTypes = []any{
+	(*Block)(nil),                        // 0: yaegpe.v1.Block
+	(*Tier)(nil),                         // 1: yaegpe.v1.Tier
+	(*GetGasPricesRequest)(nil),          // 2: yaegpe.v1.GetGasPricesRequest
+	(*WatchGasPricesRequest)(nil),        // 3: yaegpe.v1.WatchGasPricesRequest
+	(*GasPrices)(nil),                    // 4: yaegpe.v1.GasPrices
+	(*GetFeesRequest)(nil),               // 5: yaegpe.v1.GetFeesRequest
+	(*Fee)(nil),                          // 6: yaegpe.v1.Fee
+	(*GetFeesResponse)(nil),              // 7: yaegpe.v1.GetFeesResponse
+	(*GetBaseFeeProjectionRequest)(nil),  // 8: yaegpe.v1.GetBaseFeeProjectionRequest
+	(*BaseFeeBound)(nil),                 // 9: yaegpe.v1.BaseFeeBound
+	(*GetBaseFeeProjectionResponse)(nil), // 10: yaegpe.v1.GetBaseFeeProjectionResponse
+}
+var file_yaegpe_v1_yaegpe_proto_depIdxs = []int32{
+	0,  // 0: yaegpe.v1.GasPrices.block:type_name -> yaegpe.v1.Block
+	0,  // 1: yaegpe.v1.GasPrices.latest:type_name -> yaegpe.v1.Block
+	1,  // 2: yaegpe.v1.GasPrices.prices:type_name -> yaegpe.v1.Tier
+	1,  // 3: yaegpe.v1.GasPrices.blob_prices:type_name -> yaegpe.v1.Tier
+	0,  // 4: yaegpe.v1.GetFeesResponse.block:type_name -> yaegpe.v1.Block
+	6,  // 5: yaegpe.v1.GetFeesResponse.fees:type_name -> yaegpe.v1.Fee
+	0,  // 6: yaegpe.v1.GetBaseFeeProjectionResponse.block:type_name -> yaegpe.v1.Block
+	9,  // 7: yaegpe.v1.GetBaseFeeProjectionResponse.bounds:type_name -> yaegpe.v1.BaseFeeBound
+	2,  // 8: yaegpe.v1.GasPriceService.GetGasPrices:input_type -> yaegpe.v1.GetGasPricesRequest
+	5,  // 9: yaegpe.v1.GasPriceService.GetFees:input_type -> yaegpe.v1.GetFeesRequest
+	8,  // 10: yaegpe.v1.GasPriceService.GetBaseFeeProjection:input_type -> yaegpe.v1.GetBaseFeeProjectionRequest
+	3,  // 11: yaegpe.v1.GasPriceService.WatchGasPrices:input_type -> yaegpe.v1.WatchGasPricesRequest
+	4,  // 12: yaegpe.v1.GasPriceService.GetGasPrices:output_type -> yaegpe.v1.GasPrices
+	7,  // 13: yaegpe.v1.GasPriceService.GetFees:output_type -> yaegpe.v1.GetFeesResponse
+	10, // 14: yaegpe.v1.GasPriceService.GetBaseFeeProjection:output_type -> yaegpe.v1.GetBaseFeeProjectionResponse
+	4,  // 15: yaegpe.v1.GasPriceService.WatchGasPrices:output_type -> yaegpe.v1.GasPrices
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_yaegpe_v1_yaegpe_proto_init() }
+func file_yaegpe_v1_yaegpe_proto_init() {
+	if File_yaegpe_v1_yaegpe_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_yaegpe_v1_yaegpe_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Block); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Tier); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*GetGasPricesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*WatchGasPricesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*GasPrices); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*GetFeesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*Fee); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*GetFeesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*GetBaseFeeProjectionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*BaseFeeBound); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yaegpe_v1_yaegpe_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*GetBaseFeeProjectionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_yaegpe_v1_yaegpe_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_yaegpe_v1_yaegpe_proto_goTypes,
+		DependencyIndexes: file_yaegpe_v1_yaegpe_proto_depIdxs,
+		MessageInfos:      file_yaegpe_v1_yaegpe_proto_msgTypes,
+	}.Build()
+	File_yaegpe_v1_yaegpe_proto = out.File
+	file_yaegpe_v1_yaegpe_proto_rawDesc = nil
+	file_yaegpe_v1_yaegpe_proto_goTypes = nil
+	file_yaegpe_v1_yaegpe_proto_depIdxs = nil
+}
@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: yaegpe/v1/yaegpe.proto
+
+package yaegpev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GasPriceService_GetGasPrices_FullMethodName         = "/yaegpe.v1.GasPriceService/GetGasPrices"
+	GasPriceService_GetFees_FullMethodName              = "/yaegpe.v1.GasPriceService/GetFees"
+	GasPriceService_GetBaseFeeProjection_FullMethodName = "/yaegpe.v1.GasPriceService/GetBaseFeeProjection"
+	GasPriceService_WatchGasPrices_FullMethodName       = "/yaegpe.v1.GasPriceService/WatchGasPrices"
+)
+
+// GasPriceServiceClient is the client API for GasPriceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GasPriceService serves the same estimates as the HTTP API. Amounts are
+// decimal strings of wei since they may not fit in 64 bits.
+type GasPriceServiceClient interface {
+	GetGasPrices(ctx context.Context, in *GetGasPricesRequest, opts ...grpc.CallOption) (*GasPrices, error)
+	// GetFees returns the EIP-1559 fee caps of each tier.
+	GetFees(ctx context.Context, in *GetFeesRequest, opts ...grpc.CallOption) (*GetFeesResponse, error)
+	// GetBaseFeeProjection returns the range the base fee can reach in the
+	// following blocks.
+	GetBaseFeeProjection(ctx context.Context, in *GetBaseFeeProjectionRequest, opts ...grpc.CallOption) (*GetBaseFeeProjectionResponse, error)
+	// WatchGasPrices streams the current estimate followed by every new one.
+	WatchGasPrices(ctx context.Context, in *WatchGasPricesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GasPrices], error)
+}
+
+type gasPriceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGasPriceServiceClient(cc grpc.ClientConnInterface) GasPriceServiceClient {
+	return &gasPriceServiceClient{cc}
+}
+
+func (c *gasPriceServiceClient) GetGasPrices(ctx context.Context, in *GetGasPricesRequest, opts ...grpc.CallOption) (*GasPrices, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GasPrices)
+	err := c.cc.Invoke(ctx, GasPriceService_GetGasPrices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gasPriceServiceClient) GetFees(ctx context.Context, in *GetFeesRequest, opts ...grpc.CallOption) (*GetFeesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFeesResponse)
+	err := c.cc.Invoke(ctx, GasPriceService_GetFees_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gasPriceServiceClient) GetBaseFeeProjection(ctx context.Context, in *GetBaseFeeProjectionRequest, opts ...grpc.CallOption) (*GetBaseFeeProjectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBaseFeeProjectionResponse)
+	err := c.cc.Invoke(ctx, GasPriceService_GetBaseFeeProjection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gasPriceServiceClient) WatchGasPrices(ctx context.Context, in *WatchGasPricesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GasPrices], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GasPriceService_ServiceDesc.Streams[0], GasPriceService_WatchGasPrices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchGasPricesRequest, GasPrices]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GasPriceService_WatchGasPricesClient = grpc.ServerStreamingClient[GasPrices]
+
+// GasPriceServiceServer is the server API for GasPriceService service.
+// All implementations must embed UnimplementedGasPriceServiceServer
+// for forward compatibility.
+//
+// GasPriceService serves the same estimates as the HTTP API. Amounts are
+// decimal strings of wei since they may not fit in 64 bits.
+type GasPriceServiceServer interface {
+	GetGasPrices(context.Context, *GetGasPricesRequest) (*GasPrices, error)
+	// GetFees returns the EIP-1559 fee caps of each tier.
+	GetFees(context.Context, *GetFeesRequest) (*GetFeesResponse, error)
+	// GetBaseFeeProjection returns the range the base fee can reach in the
+	// following blocks.
+	GetBaseFeeProjection(context.Context, *GetBaseFeeProjectionRequest) (*GetBaseFeeProjectionResponse, error)
+	// WatchGasPrices streams the current estimate followed by every new one.
+	WatchGasPrices(*WatchGasPricesRequest, grpc.ServerStreamingServer[GasPrices]) error
+	mustEmbedUnimplementedGasPriceServiceServer()
+}
+
+// UnimplementedGasPriceServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGasPriceServiceServer struct{}
+
+func (UnimplementedGasPriceServiceServer) GetGasPrices(context.Context, *GetGasPricesRequest) (*GasPrices, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGasPrices not implemented")
+}
+func (UnimplementedGasPriceServiceServer) GetFees(context.Context, *GetFeesRequest) (*GetFeesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFees not implemented")
+}
+func (UnimplementedGasPriceServiceServer) GetBaseFeeProjection(context.Context, *GetBaseFeeProjectionRequest) (*GetBaseFeeProjectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBaseFeeProjection not implemented")
+}
+func (UnimplementedGasPriceServiceServer) WatchGasPrices(*WatchGasPricesRequest, grpc.ServerStreamingServer[GasPrices]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchGasPrices not implemented")
+}
+func (UnimplementedGasPriceServiceServer) mustEmbedUnimplementedGasPriceServiceServer() {}
+func (UnimplementedGasPriceServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeGasPriceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GasPriceServiceServer will
+// result in compilation errors.
+type UnsafeGasPriceServiceServer interface {
+	mustEmbedUnimplementedGasPriceServiceServer()
+}
+
+func RegisterGasPriceServiceServer(s grpc.ServiceRegistrar, srv GasPriceServiceServer) {
+	// If the following call pancis, it indicates UnimplementedGasPriceServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GasPriceService_ServiceDesc, srv)
+}
+
+func _GasPriceService_GetGasPrices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGasPricesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GasPriceServiceServer).GetGasPrices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GasPriceService_GetGasPrices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GasPriceServiceServer).GetGasPrices(ctx, req.(*GetGasPricesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GasPriceService_GetFees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GasPriceServiceServer).GetFees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GasPriceService_GetFees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GasPriceServiceServer).GetFees(ctx, req.(*GetFeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GasPriceService_GetBaseFeeProjection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBaseFeeProjectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GasPriceServiceServer).GetBaseFeeProjection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GasPriceService_GetBaseFeeProjection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GasPriceServiceServer).GetBaseFeeProjection(ctx, req.(*GetBaseFeeProjectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GasPriceService_WatchGasPrices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchGasPricesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GasPriceServiceServer).WatchGasPrices(m, &grpc.GenericServerStream[WatchGasPricesRequest, GasPrices]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GasPriceService_WatchGasPricesServer = grpc.ServerStreamingServer[GasPrices]
+
+// GasPriceService_ServiceDesc is the grpc.ServiceDesc for GasPriceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GasPriceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "yaegpe.v1.GasPriceService",
+	HandlerType: (*GasPriceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetGasPrices",
+			Handler:    _GasPriceService_GetGasPrices_Handler,
+		},
+		{
+			MethodName: "GetFees",
+			Handler:    _GasPriceService_GetFees_Handler,
+		},
+		{
+			MethodName: "GetBaseFeeProjection",
+			Handler:    _GasPriceService_GetBaseFeeProjection_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchGasPrices",
+			Handler:       _GasPriceService_WatchGasPrices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "yaegpe/v1/yaegpe.proto",
+}
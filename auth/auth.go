@@ -0,0 +1,351 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrBadKeys = errors.New("api keys are invalid")
+
+// Key is an API key with its own rate limit in requests per second.
+type Key struct {
+	Key   string  `json:"key"`
+	Name  string  `json:"name"`
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Guard authenticates requests by API key, if keys are loaded, and limits
+// them per key and per client IP with token buckets.
+type Guard struct {
+	path       string
+	keys       map[string]Key
+	modified   time.Time
+	keyBuckets map[string]*bucket
+	ipBuckets  map[string]*bucket
+	ipRate     float64
+	ipBurst    int
+	proxies    []netip.Prefix
+	usage      map[string]uint64
+	lock       sync.Mutex
+}
+
+// NewGuard loads the keys of the JSON file at path, or requires no key if
+// path is empty. A zero IP rate disables the per IP limit. Requests from the
+// trusted proxies are limited by the client address they forward instead.
+func NewGuard(path string, ipRate float64, ipBurst int, proxies []netip.Prefix) (*Guard, error) {
+	g := &Guard{
+		path,
+		nil,
+		time.Time{},
+		make(map[string]*bucket),
+		make(map[string]*bucket),
+		ipRate,
+		ipBurst,
+		proxies,
+		make(map[string]uint64),
+		sync.Mutex{},
+	}
+	if path != "" {
+		if err := g.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func loadKeys(path string) (map[string]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []Key
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]Key)
+	for _, key := range list {
+		if key.Key == "" || key.Name == "" || key.Rate <= 0 || key.Burst < 1 {
+			return nil, ErrBadKeys
+		}
+		keys[key.Key] = key
+	}
+	return keys, nil
+}
+
+// reload loads the keys again if the file changed, keeping the buckets of
+// the keys still there.
+func (g *Guard) reload() error {
+	info, err := os.Stat(g.path)
+	if err != nil {
+		return err
+	}
+	g.lock.Lock()
+	modified := g.modified
+	g.lock.Unlock()
+	if info.ModTime().Equal(modified) {
+		return nil
+	}
+
+	keys, err := loadKeys(g.path)
+	if err != nil {
+		return err
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.keys = keys
+	g.modified = info.ModTime()
+	for k, b := range g.keyBuckets {
+		key, ok := keys[k]
+		if !ok {
+			delete(g.keyBuckets, k)
+			continue
+		}
+		b.rate = key.Rate
+		b.burst = float64(key.Burst)
+	}
+	return nil
+}
+
+// Watch reloads the keys when their file changes and forgets the idle IPs
+// every interval until the context is done.
+func (g *Guard) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if g.path != "" {
+				if err := g.reload(); err != nil {
+					slog.Error("could not reload api keys", "err", err)
+				}
+			}
+			g.prune(time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (g *Guard) prune(now time.Time) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for ip, b := range g.ipBuckets {
+		if b.full(now) {
+			delete(g.ipBuckets, ip)
+		}
+	}
+}
+
+func requestKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return bearer
+	}
+	return ""
+}
+
+func (g *Guard) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, proxy := range g.proxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedIP returns the address of the client behind the peer. Only the
+// trusted proxies are believed, so it is the right-most address of the
+// X-Forwarded-For values that is not one of them, as the ones before it can
+// be made up by the client.
+func (g *Guard) forwardedIP(peer string, forwarded []string) string {
+	if !g.trusted(peer) {
+		return peer
+	}
+	addrs := strings.Split(strings.Join(forwarded, ","), ",")
+	ip := peer
+	for i := len(addrs) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(addrs[i])
+		if _, err := netip.ParseAddr(addr); err != nil {
+			break
+		}
+		ip = addr
+		if !g.trusted(addr) {
+			break
+		}
+	}
+	return ip
+}
+
+func (g *Guard) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return g.forwardedIP(host, r.Header.Values("X-Forwarded-For"))
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errorResponse{message})
+}
+
+type contextKey struct{}
+
+// WithKeyName returns a context carrying the name of the key a request was
+// admitted with.
+func WithKeyName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// KeyName returns the name of the key the request of the context was
+// admitted with, or an empty string if no keys are loaded.
+func KeyName(ctx context.Context) string {
+	name, _ := ctx.Value(contextKey{}).(string)
+	return name
+}
+
+// admit checks the rate limits and the key of a request and counts it. It
+// returns the name of the key, the status code to reject it with, and how
+// long to wait on 429.
+// The IP is charged before the key is checked so guessing keys is limited
+// too.
+func (g *Guard) admit(key string, ip string, now time.Time) (string, int, time.Duration) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.ipRate > 0 {
+		b, ok := g.ipBuckets[ip]
+		if !ok {
+			b = newBucket(g.ipRate, g.ipBurst, now)
+			g.ipBuckets[ip] = b
+		}
+		if ok, wait := b.take(now); !ok {
+			return "", http.StatusTooManyRequests, wait
+		}
+	}
+
+	var k Key
+	if g.keys != nil {
+		var ok bool
+		if k, ok = g.keys[key]; !ok {
+			return "", http.StatusUnauthorized, 0
+		}
+	}
+	if g.keys != nil {
+		b, ok := g.keyBuckets[key]
+		if !ok {
+			b = newBucket(k.Rate, k.Burst, now)
+			g.keyBuckets[key] = b
+		}
+		if ok, wait := b.take(now); !ok {
+			return "", http.StatusTooManyRequests, wait
+		}
+		g.usage[k.Name]++
+	}
+	return k.Name, http.StatusOK, 0
+}
+
+// retryAfter formats the wait in whole seconds, rounded up.
+func retryAfter(wait time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10)
+}
+
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, code, wait := g.admit(requestKey(r), g.clientIP(r), time.Now())
+		switch code {
+		case http.StatusUnauthorized:
+			writeError(w, code, "invalid api key")
+		case http.StatusTooManyRequests:
+			w.Header().Set("Retry-After", retryAfter(wait))
+			writeError(w, code, "rate limit exceeded")
+		default:
+			if name != "" {
+				w = &privateWriter{w, false}
+				r = r.WithContext(WithKeyName(r.Context(), name))
+			}
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// privateWriter turns the public Cache-Control of a response into a private
+// one, so shared caches do not serve it to requests without a valid key.
+type privateWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (p *privateWriter) WriteHeader(status int) {
+	if !p.wroteHeader {
+		p.wroteHeader = true
+		if control := p.Header().Get("Cache-Control"); strings.Contains(control, "public") {
+			p.Header().Set("Cache-Control", strings.Replace(control, "public", "private", 1))
+		}
+	}
+	p.ResponseWriter.WriteHeader(status)
+}
+
+func (p *privateWriter) Write(b []byte) (int, error) {
+	if !p.wroteHeader {
+		p.WriteHeader(http.StatusOK)
+	}
+	return p.ResponseWriter.Write(b)
+}
+
+func (p *privateWriter) Flush() {
+	if !p.wroteHeader {
+		p.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := p.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (p *privateWriter) Unwrap() http.ResponseWriter {
+	return p.ResponseWriter
+}
+
+type usageResponse struct {
+	Name     string `json:"name"`
+	Requests uint64 `json:"requests"`
+}
+
+// ServeHTTP reports the number of requests made with the key of the request.
+func (g *Guard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.lock.Lock()
+	key, ok := g.keys[requestKey(r)]
+	requests := g.usage[key.Name]
+	g.lock.Unlock()
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+	if err := json.NewEncoder(w).Encode(usageResponse{key.Name, requests}); err != nil {
+		slog.Warn("could not encode usage", "err", err)
+	}
+}
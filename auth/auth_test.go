@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func writeKeys(t *testing.T, path string, data string, modified time.Time) {
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBucket(2, 2, now)
+	for i := 0; i < 2; i++ {
+		if ok, _ := b.take(now); !ok {
+			t.Fatalf("take %d failed", i)
+		}
+	}
+	ok, wait := b.take(now)
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %v %v", ok, wait)
+	}
+	if ok, _ := b.take(now.Add(500 * time.Millisecond)); !ok {
+		t.Fatal("expected a token after 500ms")
+	}
+	if !b.full(now.Add(time.Hour)) {
+		t.Fatal("expected a full bucket")
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeys(t, path, `[{"key": "a", "name": "partner", "rate": 1, "burst": 2}]`, time.Unix(1, 0))
+	g, err := NewGuard(path, 1, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(0, 0)
+	tests := []struct {
+		key  string
+		ip   string
+		code int
+		wait time.Duration
+	}{
+		{"", "1.1.1.1", http.StatusUnauthorized, 0},
+		{"b", "1.1.1.1", http.StatusUnauthorized, 0},
+		{"a", "1.1.1.1", http.StatusOK, 0},
+		{"b", "1.1.1.1", http.StatusTooManyRequests, time.Second},
+		{"a", "2.2.2.2", http.StatusOK, 0},
+		{"a", "3.3.3.3", http.StatusTooManyRequests, time.Second},
+	}
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			_, code, wait := g.admit(test.key, test.ip, now)
+			if code != test.code || wait != test.wait {
+				t.Fatalf("expected %d %v, got %d %v", test.code, test.wait, code, wait)
+			}
+		})
+	}
+	if g.usage["partner"] != 2 {
+		t.Fatalf("expected 2 requests, got %d", g.usage["partner"])
+	}
+}
+
+func TestMiddlewareKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeys(t, path, `[{"key": "a", "name": "partner", "rate": 10, "burst": 10}]`, time.Unix(1, 0))
+	g, err := NewGuard(path, 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := g.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=6")
+		w.Write([]byte(KeyName(r.Context())))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/?api_key=a", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a key in the query to be ignored, got %d", w.Code)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-API-Key", "a")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w.Body.String() != "partner" {
+		t.Fatalf("expected the name of the key in the context, got %q", w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") != "private, max-age=6" {
+		t.Fatalf("expected a private response, got %s", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeys(t, path, `[{"key": "a", "name": "old", "rate": 1, "burst": 1}]`, time.Unix(1, 0))
+	g, err := NewGuard(path, 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeKeys(t, path, `[{"key": "b", "name": "new", "rate": 1, "burst": 1}]`, time.Unix(2, 0))
+	if err := g.reload(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(0, 0)
+	if _, code, _ := g.admit("a", "1.1.1.1", now); code != http.StatusUnauthorized {
+		t.Fatalf("expected the old key to be rejected, got %d", code)
+	}
+	if _, code, _ := g.admit("b", "1.1.1.1", now); code != http.StatusOK {
+		t.Fatalf("expected the new key to be accepted, got %d", code)
+	}
+
+	writeKeys(t, path, `[{"key": "b", "name": "new", "rate": 0, "burst": 1}]`, time.Unix(3, 0))
+	if err := g.reload(); err == nil {
+		t.Fatal("expected bad keys to be rejected")
+	}
+	if _, code, _ := g.admit("b", "1.1.1.1", now.Add(time.Second)); code != http.StatusOK {
+		t.Fatalf("expected the keys to be kept, got %d", code)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	g, err := NewGuard("", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := g.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected to retry after 1s, got %s", w.Header().Get("Retry-After"))
+	}
+	if w.Body.String() != "{\"error\":\"rate limit exceeded\"}\n" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+	g, err := NewGuard("", 0, 0, proxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote    string
+		forwarded []string
+		expected  string
+	}{
+		{"1.1.1.1:1234", nil, "1.1.1.1"},
+		{"1.1.1.1:1234", []string{"2.2.2.2"}, "1.1.1.1"},
+		{"10.0.0.1:1234", nil, "10.0.0.1"},
+		{"10.0.0.1:1234", []string{"2.2.2.2"}, "2.2.2.2"},
+		{"10.0.0.1:1234", []string{"3.3.3.3, 2.2.2.2"}, "2.2.2.2"},
+		{"10.0.0.1:1234", []string{"2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		{"10.0.0.1:1234", []string{"3.3.3.3", "2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		{"10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"10.0.0.1:1234", []string{"2.2.2.2, garbage"}, "10.0.0.1"},
+		{"[::ffff:10.0.0.1]:1234", []string{"2.2.2.2"}, "2.2.2.2"},
+		{"[fd00::1]:1234", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remote
+			for _, value := range test.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if ip := g.clientIP(r); ip != test.expected {
+				t.Errorf("clientIP returned %s but expected %s", ip, test.expected)
+			}
+		})
+	}
+}
+
+type streamMock struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *streamMock) Context() context.Context { return s.ctx }
+
+func (s *streamMock) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestInterceptors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeys(t, path, `[{"key": "a", "name": "partner", "rate": 1, "burst": 1}]`, time.Unix(1, 0))
+	g, err := NewGuard(path, 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := &net.TCPAddr{IP: net.IPv4(1, 1, 1, 1), Port: 1234}
+	newContext := func(pairs ...string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(pairs...))
+	}
+	unary := func(ctx context.Context, req interface{}) (interface{}, error) { return KeyName(ctx), nil }
+
+	if _, err := g.UnaryInterceptor(newContext(), nil, &grpc.UnaryServerInfo{}, unary); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+	resp, err := g.UnaryInterceptor(newContext("authorization", "Bearer a"), nil, &grpc.UnaryServerInfo{}, unary)
+	if err != nil || resp != "partner" {
+		t.Fatalf("expected the call to be handled with the key name, got %v %v", resp, err)
+	}
+
+	stream := &streamMock{ctx: newContext("x-api-key", "a")}
+	handled := false
+	err = g.StreamInterceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		handled = true
+		return nil
+	})
+	if status.Code(err) != codes.ResourceExhausted || handled {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	if values := stream.header.Get("retry-after"); len(values) != 1 || values[0] != "1" {
+		t.Fatalf("expected to retry after 1s, got %v", values)
+	}
+	if g.usage["partner"] != 1 {
+		t.Fatalf("expected 1 request, got %d", g.usage["partner"])
+	}
+}
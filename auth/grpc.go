@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func metadataKey(md metadata.MD) string {
+	if keys := md.Get("x-api-key"); len(keys) > 0 && keys[0] != "" {
+		return keys[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if bearer, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+			return bearer
+		}
+	}
+	return ""
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// check admits a call like Middleware admits a request, sending how long to
+// wait in the retry-after header when it is rate limited. It returns the
+// context of the call with the name of its key.
+func (g *Guard) check(ctx context.Context, setHeader func(metadata.MD) error) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	name, code, wait := g.admit(metadataKey(md), g.forwardedIP(peerIP(ctx), md.Get("x-forwarded-for")), time.Now())
+	switch code {
+	case http.StatusUnauthorized:
+		return nil, status.Error(codes.Unauthenticated, "invalid api key")
+	case http.StatusTooManyRequests:
+		setHeader(metadata.Pairs("retry-after", retryAfter(wait)))
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	if name != "" {
+		ctx = WithKeyName(ctx, name)
+	}
+	return ctx, nil
+}
+
+// keyedStream is a stream whose context carries the name of its key.
+type keyedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s keyedStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryInterceptor authenticates and limits unary gRPC calls, taking the key
+// from the x-api-key or authorization metadata.
+func (g *Guard) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	setHeader := func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }
+	ctx, err := g.check(ctx, setHeader)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor authenticates and limits gRPC streams when they are
+// opened.
+func (g *Guard) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := g.check(ss.Context(), ss.SetHeader)
+	if err != nil {
+		return err
+	}
+	return handler(srv, keyedStream{ss, ctx})
+}
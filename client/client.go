@@ -0,0 +1,403 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var ErrBadResponse = errors.New("response is invalid")
+var ErrNoTier = errors.New("tier does not exist")
+
+const firstRetryDelay = 100 * time.Millisecond
+const maxWatchDelay = 30 * time.Second
+
+// formatQuery requests the amounts as decimal strings of wei, the only format
+// parseResponse accepts, whatever the defaults of the server are.
+const formatQuery = "?unit=wei&format=decimal"
+
+type Block struct {
+	Number uint64
+	Hash   common.Hash
+	Time   uint64
+}
+
+// GasPrices is an estimate in wei. The next base fee is nil on chains
+// without one and the blob fields are nil on chains without blobs.
+type GasPrices struct {
+	Block       Block
+	Latest      Block
+	Prices      map[string]*big.Int
+	NextBaseFee *big.Int
+	BlobBaseFee *big.Int
+	BlobPrices  map[string]*big.Int
+}
+
+// Fee is the pair of EIP-1559 fee caps for a tier.
+type Fee struct {
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// Fee returns the EIP-1559 caps of the tier, computed like the fees of the
+// gRPC API. The tip is what the tier pays above the next base fee, and the
+// fee cap leaves room for the base fee to double. Without a base fee both
+// caps are the tier price.
+func (g *GasPrices) Fee(tier string) (Fee, error) {
+	price, ok := g.Prices[tier]
+	if !ok {
+		return Fee{}, ErrNoTier
+	}
+	if g.NextBaseFee == nil {
+		return Fee{new(big.Int).Set(price), new(big.Int).Set(price)}, nil
+	}
+	tip := new(big.Int).Sub(price, g.NextBaseFee)
+	if tip.Sign() < 0 {
+		tip.SetInt64(0)
+	}
+	maxFee := new(big.Int).Mul(g.NextBaseFee, big.NewInt(2))
+	return Fee{maxFee.Add(maxFee, tip), tip}, nil
+}
+
+// Client requests the estimates from a yaegpe server, retrying failed
+// requests and server errors with an exponential backoff, and caches them for
+// the cache duration if it is not zero.
+type Client struct {
+	url        string
+	apiKey     string
+	http       *http.Client
+	retries    int
+	cacheTTL   time.Duration
+	cached     *GasPrices
+	cachedAt   time.Time
+	retryDelay time.Duration
+	lock       sync.Mutex
+}
+
+func New(url string, timeout time.Duration, retries int, cacheTTL time.Duration) *Client {
+	return &Client{
+		strings.TrimSuffix(url, "/"),
+		"",
+		&http.Client{Timeout: timeout},
+		retries,
+		cacheTTL,
+		nil,
+		time.Time{},
+		firstRetryDelay,
+		sync.Mutex{},
+	}
+}
+
+// WithAPIKey makes the client send the key in the X-API-Key header of its
+// requests, for servers started with -api-keys. It must be called before the
+// client is used.
+func (c *Client) WithAPIKey(key string) *Client {
+	c.apiKey = key
+	return c
+}
+
+// newRequest creates a GET request of the path carrying the API key.
+func (c *Client) newRequest(ctx context.Context, path string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path+formatQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	return req, nil
+}
+
+type blockResponse struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Time   uint64      `json:"timestamp"`
+}
+
+type response struct {
+	Block       blockResponse     `json:"block"`
+	Latest      blockResponse     `json:"latest"`
+	Prices      map[string]string `json:"prices"`
+	NextBaseFee *string           `json:"nextBaseFee"`
+	BlobBaseFee *string           `json:"blobBaseFee"`
+	BlobPrices  map[string]string `json:"blobPrices"`
+}
+
+// parseAmount parses a decimal string of wei. Amounts in other units or
+// formats, such as fractions of gwei, hex strings or JSON numbers, are
+// rejected rather than misread.
+func parseAmount(raw *string) (*big.Int, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if *raw == "" || strings.Trim(*raw, "0123456789") != "" {
+		return nil, fmt.Errorf("%w: amount %q is not a decimal of wei", ErrBadResponse, *raw)
+	}
+	x, _ := new(big.Int).SetString(*raw, 10)
+	return x, nil
+}
+
+func parseTiers(raw map[string]string) (map[string]*big.Int, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	tiers := make(map[string]*big.Int)
+	for name, price := range raw {
+		x, err := parseAmount(&price)
+		if err != nil {
+			return nil, err
+		}
+		tiers[name] = x
+	}
+	return tiers, nil
+}
+
+func parseResponse(data []byte) (*GasPrices, error) {
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadResponse, err)
+	}
+
+	g := &GasPrices{
+		Block:  Block(resp.Block),
+		Latest: Block(resp.Latest),
+	}
+	var err error
+	if g.Prices, err = parseTiers(resp.Prices); err != nil {
+		return nil, err
+	}
+	if g.NextBaseFee, err = parseAmount(resp.NextBaseFee); err != nil {
+		return nil, err
+	}
+	if g.BlobBaseFee, err = parseAmount(resp.BlobBaseFee); err != nil {
+		return nil, err
+	}
+	if g.BlobPrices, err = parseTiers(resp.BlobPrices); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func clone(g *GasPrices) *GasPrices {
+	c := *g
+	c.Prices = cloneTiers(g.Prices)
+	c.BlobPrices = cloneTiers(g.BlobPrices)
+	if g.NextBaseFee != nil {
+		c.NextBaseFee = new(big.Int).Set(g.NextBaseFee)
+	}
+	if g.BlobBaseFee != nil {
+		c.BlobBaseFee = new(big.Int).Set(g.BlobBaseFee)
+	}
+	return &c
+}
+
+func cloneTiers(tiers map[string]*big.Int) map[string]*big.Int {
+	if tiers == nil {
+		return nil
+	}
+	c := make(map[string]*big.Int)
+	for name, price := range tiers {
+		c[name] = new(big.Int).Set(price)
+	}
+	return c
+}
+
+func (c *Client) store(g *GasPrices) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cached = clone(g)
+	c.cachedAt = time.Now()
+}
+
+func (c *Client) get(ctx context.Context) ([]byte, bool, error) {
+	req, err := c.newRequest(ctx, "/v1/")
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("%w: %s", ErrBadResponse, resp.Status)
+	}
+	return data, false, nil
+}
+
+// GasPrices returns the current estimate.
+func (c *Client) GasPrices(ctx context.Context) (*GasPrices, error) {
+	c.lock.Lock()
+	if c.cached != nil && time.Since(c.cachedAt) < c.cacheTTL {
+		cached := clone(c.cached)
+		c.lock.Unlock()
+		return cached, nil
+	}
+	c.lock.Unlock()
+
+	delay := c.retryDelay
+	for attempt := 0; ; attempt++ {
+		data, retry, err := c.get(ctx)
+		if err == nil {
+			g, err := parseResponse(data)
+			if err != nil {
+				return nil, err
+			}
+			c.store(g)
+			return g, nil
+		}
+		if !retry || attempt == c.retries {
+			return nil, err
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// connect opens the stream of estimates, resuming after the event with the
+// id unless it is empty. It returns whether a failure is worth retrying.
+func (c *Client) connect(ctx context.Context, lastID string) (*http.Response, bool, error) {
+	req, err := c.newRequest(ctx, "/v1/stream")
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	// the timeout of the client would end the stream
+	resp, err := (&http.Client{Transport: c.http.Transport}).Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("%w: %s", ErrBadResponse, resp.Status)
+	}
+	return resp, false, nil
+}
+
+// read sends the estimates of the stream until it ends and returns the id of
+// the last event, whether it received any, and an error if an event is
+// invalid or the context is done.
+func (c *Client) read(ctx context.Context, body io.Reader, ch chan<- *GasPrices, lastID string) (string, bool, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	received := false
+	id := lastID
+	var data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "id: "); ok {
+			id = value
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data: "); ok {
+			data = value
+			continue
+		}
+		if line != "" || data == "" {
+			continue
+		}
+
+		g, err := parseResponse([]byte(data))
+		if err != nil {
+			return lastID, received, err
+		}
+		data = ""
+		lastID = id
+		received = true
+		c.store(g)
+		select {
+		case ch <- g:
+		case <-ctx.Done():
+			return lastID, received, ctx.Err()
+		}
+	}
+	return lastID, received, nil
+}
+
+// Watch streams the current estimate and every new one until the context is
+// done, which closes the channel. A dropped stream is reopened with an
+// exponential backoff and resumes after the last received estimate, so it
+// is not sent again. The channel is also closed if the server sends an
+// invalid estimate or rejects the stream with a client error.
+func (c *Client) Watch(ctx context.Context) (<-chan *GasPrices, error) {
+	resp, _, err := c.connect(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *GasPrices)
+	go func() {
+		defer close(ch)
+		lastID := ""
+		delay := c.retryDelay
+		for {
+			var received bool
+			lastID, received, err = c.read(ctx, resp.Body, ch, lastID)
+			resp.Body.Close()
+			if err != nil || ctx.Err() != nil {
+				return
+			}
+			if received {
+				delay = c.retryDelay
+			}
+
+			for {
+				select {
+				case <-time.After(delay):
+					delay = min(2*delay, maxWatchDelay)
+				case <-ctx.Done():
+					return
+				}
+				var retry bool
+				resp, retry, err = c.connect(ctx, lastID)
+				if err == nil {
+					break
+				}
+				if !retry {
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// FillDynamicFeeTx sets the fee caps of the transaction to the ones of the
+// tier.
+func (c *Client) FillDynamicFeeTx(ctx context.Context, tx *types.DynamicFeeTx, tier string) error {
+	g, err := c.GasPrices(ctx)
+	if err != nil {
+		return err
+	}
+	fee, err := g.Fee(tier)
+	if err != nil {
+		return err
+	}
+	tx.GasFeeCap = fee.MaxFeePerGas
+	tx.GasTipCap = fee.MaxPriorityFeePerGas
+	return nil
+}
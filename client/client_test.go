@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ArmanMazdaee/yaegpe/handler"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var blockMock = gasprice.Block{Number: 42, Hash: common.HexToHash("0x2a"), Time: 1646000000}
+
+type estimatorMock struct {
+	calls *int32
+	ch    chan gasprice.Estimate
+}
+
+func (e estimatorMock) GasPrices(ctx context.Context) (gasprice.Estimate, error) {
+	atomic.AddInt32(e.calls, 1)
+	return gasprice.Estimate{
+		Block:       blockMock,
+		Latest:      blockMock,
+		Prices:      []*big.Int{big.NewInt(90), big.NewInt(130)},
+		NextBaseFee: big.NewInt(100),
+	}, nil
+}
+
+func (e estimatorMock) Subscribe() (<-chan gasprice.Estimate, func()) {
+	return e.ch, func() {}
+}
+
+func newServer(estimator estimatorMock, failures int32) *httptest.Server {
+	root := handler.New(estimator, []string{"low", "high"}, nil, 0)
+	mux := http.NewServeMux()
+	mux.Handle("/v1/stream", handler.NewStream(root, estimator))
+	mux.Handle("/v1/{$}", root)
+	var requests int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestClientGasPrices(t *testing.T) {
+	var calls int32
+	server := newServer(estimatorMock{&calls, nil}, 2)
+	defer server.Close()
+	client := New(server.URL, time.Second, 2, time.Minute)
+	client.retryDelay = time.Millisecond
+
+	g, err := client.GasPrices(context.Background())
+	if err != nil {
+		t.Fatal("GasPrices returned error:", err)
+	}
+	if g.Block.Hash != blockMock.Hash || g.Prices["high"].Int64() != 130 || g.NextBaseFee.Int64() != 100 {
+		t.Fatalf("GasPrices returned wrong prices: %v", g)
+	}
+
+	g.Prices["high"].SetInt64(0)
+	g, err = client.GasPrices(context.Background())
+	if err != nil || g.Prices["high"].Int64() != 130 {
+		t.Fatal("GasPrices returned a shared cached estimate")
+	}
+	if calls != 1 {
+		t.Fatalf("estimator was called %d times but expected 1", calls)
+	}
+
+	tx := &types.DynamicFeeTx{}
+	if err := client.FillDynamicFeeTx(context.Background(), tx, "high"); err != nil {
+		t.Fatal("FillDynamicFeeTx returned error:", err)
+	}
+	if tx.GasFeeCap.Int64() != 230 || tx.GasTipCap.Int64() != 30 {
+		t.Errorf("FillDynamicFeeTx set wrong caps %d and %d", tx.GasFeeCap, tx.GasTipCap)
+	}
+	if err := client.FillDynamicFeeTx(context.Background(), tx, "medium"); err != ErrNoTier {
+		t.Error("FillDynamicFeeTx accepted an unknown tier")
+	}
+}
+
+func TestGasPricesFee(t *testing.T) {
+	tests := []struct {
+		price       *big.Int
+		nextBaseFee *big.Int
+	}{
+		{big.NewInt(130), big.NewInt(100)},
+		{big.NewInt(90), big.NewInt(100)},
+		{big.NewInt(100), big.NewInt(100)},
+		{big.NewInt(130), nil},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			g := &GasPrices{Prices: map[string]*big.Int{"high": test.price}, NextBaseFee: test.nextBaseFee}
+			fee, err := g.Fee("high")
+			if err != nil {
+				t.Fatal("Fee returned error:", err)
+			}
+			// the caps are the ones the server computes
+			estimate := gasprice.Estimate{Prices: []*big.Int{test.price}, NextBaseFee: test.nextBaseFee}
+			expected := estimate.Fees()[0]
+			if fee.MaxFeePerGas.Cmp(expected.MaxFeePerGas) != 0 || fee.MaxPriorityFeePerGas.Cmp(expected.MaxPriorityFeePerGas) != 0 {
+				t.Errorf("Fee returned %d and %d but expected %d and %d", fee.MaxFeePerGas, fee.MaxPriorityFeePerGas, expected.MaxFeePerGas, expected.MaxPriorityFeePerGas)
+			}
+		})
+	}
+}
+
+func TestClientGasPricesRetries(t *testing.T) {
+	var calls int32
+	server := newServer(estimatorMock{&calls, nil}, 3)
+	defer server.Close()
+	client := New(server.URL, time.Second, 2, 0)
+	client.retryDelay = time.Millisecond
+
+	if _, err := client.GasPrices(context.Background()); err == nil {
+		t.Fatal("GasPrices should fail after the retries")
+	}
+}
+
+func TestClientWatch(t *testing.T) {
+	var calls int32
+	ch := make(chan gasprice.Estimate, 1)
+	server := newServer(estimatorMock{&calls, ch}, 0)
+	defer server.Close()
+	client := New(server.URL, time.Second, 0, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx)
+	if err != nil {
+		t.Fatal("Watch returned error:", err)
+	}
+	if g := <-stream; g == nil || g.Block.Number != 42 {
+		t.Fatal("Watch did not receive the current estimate")
+	}
+	block := gasprice.Block{Number: 43, Hash: common.HexToHash("0x2b")}
+	ch <- gasprice.Estimate{Block: block, Prices: []*big.Int{big.NewInt(1)}}
+	if g := <-stream; g == nil || g.Block.Number != 43 || g.Prices["low"].Int64() != 1 {
+		t.Fatal("Watch did not receive the new estimate")
+	}
+}
+
+func TestClientWatchReconnects(t *testing.T) {
+	var calls int32
+	ch := make(chan gasprice.Estimate, 1)
+	root := handler.New(estimatorMock{&calls, ch}, []string{"low", "high"}, nil, 0)
+	stream := handler.NewStream(root, estimatorMock{&calls, ch})
+	var requests int32
+	lastIDs := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastIDs <- r.Header.Get("Last-Event-ID")
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			// drop the stream after the first event
+			ctx, cancel := context.WithTimeout(r.Context(), 50*time.Millisecond)
+			defer cancel()
+			stream.ServeHTTP(w, r.WithContext(ctx))
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			stream.ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+	client := New(server.URL, time.Second, 0, 0)
+	client.retryDelay = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watch, err := client.Watch(ctx)
+	if err != nil {
+		t.Fatal("Watch returned error:", err)
+	}
+	if g := <-watch; g == nil || g.Block.Number != 42 {
+		t.Fatal("Watch did not receive the current estimate")
+	}
+	block := gasprice.Block{Number: 43, Hash: common.HexToHash("0x2b")}
+	for i := 0; i < 3; i++ {
+		if id := <-lastIDs; i > 0 && id != blockMock.Hash.Hex() {
+			t.Fatalf("request %d resumed from %q", i, id)
+		}
+	}
+	ch <- gasprice.Estimate{Block: block, Prices: []*big.Int{big.NewInt(1)}}
+	if g := <-watch; g == nil || g.Block.Number != 43 {
+		t.Fatalf("Watch did not resume with the new estimate: %v", g)
+	}
+}
+
+func TestParseResponseFormats(t *testing.T) {
+	tests := []string{
+		`{"prices": {"low": "0x5a"}}`,
+		`{"prices": {"low": "0.00000009"}}`,
+		`{"prices": {"low": 90}}`,
+		`{"prices": {"low": "-90"}}`,
+	}
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			if _, err := parseResponse([]byte(test)); !errors.Is(err, ErrBadResponse) {
+				t.Fatalf("parseResponse returned %v for %s", err, test)
+			}
+		})
+	}
+}
+
+func TestClientAPIKey(t *testing.T) {
+	var calls int32
+	server := newServer(estimatorMock{&calls, nil}, 0)
+	defer server.Close()
+	keys := make(chan string, 1)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get("X-API-Key")
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	client := New(server.URL, time.Second, 0, 0).WithAPIKey("secret")
+
+	if _, err := client.GasPrices(context.Background()); !errors.Is(err, ErrBadResponse) {
+		t.Fatalf("GasPrices returned %v on a rejected key", err)
+	}
+	if key := <-keys; key != "secret" {
+		t.Fatalf("request had key %q", key)
+	}
+}
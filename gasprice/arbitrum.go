@@ -0,0 +1,165 @@
+package gasprice
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// nodeInterfaceAddress is the address of the NodeInterface virtual contract
+// on Arbitrum chains, which is only reachable through eth_call.
+var nodeInterfaceAddress = common.HexToAddress("0x00000000000000000000000000000000000000C8")
+
+const nodeInterfaceABI = `[
+	{"name":"gasEstimateComponents","type":"function","stateMutability":"payable","inputs":[{"name":"to","type":"address"},{"name":"contractCreation","type":"bool"},{"name":"data","type":"bytes"}],"outputs":[{"name":"gasEstimate","type":"uint64"},{"name":"gasEstimateForL1","type":"uint64"},{"name":"baseFee","type":"uint256"},{"name":"l1BaseFeeEstimate","type":"uint256"}]}
+]`
+
+// L1Component is what posting a simple transaction to the L1 costs. Gas is in
+// L2 gas, which is charged at the L2 base fee, so Cost is Gas times the L2 base
+// fee in wei. L1BaseFee is the estimated L1 base fee in wei per L1 gas.
+type L1Component struct {
+	L1BaseFee *big.Int
+	Gas       uint64
+	Cost      *big.Int
+}
+
+// ArbitrumEstimator estimates gas prices on Arbitrum chains, where the L2
+// base fee is the price every transaction pays and tips are refunded, so
+// every tier is the L2 base fee reported by the NodeInterface. The L1
+// component of a simple transfer is reported separately.
+type ArbitrumEstimator struct {
+	tracker      Tracker
+	caller       Caller
+	abi          abi.ABI
+	tiers        int
+	lastEstimate *Estimate
+	fetchLock    sync.Mutex
+	lock         sync.RWMutex
+	estimateFeed
+}
+
+func NewArbitrumEstimator(
+	ctx context.Context,
+	tracker Tracker,
+	caller Caller,
+	tiers int,
+) (*ArbitrumEstimator, error) {
+	parsed, err := abi.JSON(strings.NewReader(nodeInterfaceABI))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &ArbitrumEstimator{
+		tracker,
+		caller,
+		parsed,
+		tiers,
+		nil,
+		sync.Mutex{},
+		sync.RWMutex{},
+		newEstimateFeed(),
+	}
+	go e.listen(ctx)
+
+	return e, nil
+}
+
+func (e *ArbitrumEstimator) listen(ctx context.Context) {
+	subscription := e.tracker.subscribe()
+	for {
+		select {
+		case head := <-subscription.ch:
+			if _, err := e.estimate(ctx, head); err != nil {
+				slog.Error("could not estimate gas prices", "number", head.Number, "hash", head.Hash(), "err", err)
+			}
+		case <-ctx.Done():
+			subscription.unsubscribe()
+			return
+		}
+	}
+}
+
+func (e *ArbitrumEstimator) cached(head *types.Header) *Estimate {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	if e.lastEstimate != nil && e.lastEstimate.Block.Hash == head.Hash() {
+		return e.lastEstimate
+	}
+	return nil
+}
+
+func (e *ArbitrumEstimator) estimate(ctx context.Context, head *types.Header) (Estimate, error) {
+	if estimate := e.cached(head); estimate != nil {
+		return estimate.clone(), nil
+	}
+
+	e.fetchLock.Lock()
+	defer e.fetchLock.Unlock()
+	if estimate := e.cached(head); estimate != nil {
+		return estimate.clone(), nil
+	}
+
+	data, err := e.abi.Pack("gasEstimateComponents", common.Address{}, false, []byte{})
+	if err != nil {
+		return Estimate{}, err
+	}
+	msg := ethereum.CallMsg{To: &nodeInterfaceAddress, Data: data}
+	output, err := e.caller.CallContract(ctx, msg, head.Number)
+	if err != nil {
+		return Estimate{}, err
+	}
+	values, err := e.abi.Unpack("gasEstimateComponents", output)
+	if err != nil {
+		return Estimate{}, err
+	}
+	l1Gas := values[1].(uint64)
+	baseFee := values[2].(*big.Int)
+	l1BaseFee := values[3].(*big.Int)
+
+	prices := make([]*big.Int, e.tiers)
+	for i := range prices {
+		prices[i] = new(big.Int).Set(baseFee)
+	}
+	block := newBlock(head)
+	estimate := &Estimate{
+		Block:       block,
+		Latest:      block,
+		Prices:      prices,
+		BaseFee:     cloneBigInt(head.BaseFee),
+		NextBaseFee: new(big.Int).Set(baseFee),
+		L1:          &L1Component{l1BaseFee, l1Gas, new(big.Int).Mul(baseFee, new(big.Int).SetUint64(l1Gas))},
+		Latency:     latencySince(e.tracker, head),
+	}
+
+	// calls for different heads can finish out of order, and an older head
+	// must not replace the estimate of a newer one
+	e.lock.Lock()
+	latest := e.lastEstimate == nil || estimate.Block.Number >= e.lastEstimate.Block.Number
+	if latest {
+		e.lastEstimate = estimate
+	}
+	e.lock.Unlock()
+	if latest {
+		e.publish(estimate)
+	}
+	return estimate.clone(), nil
+}
+
+func (e *ArbitrumEstimator) GasPrices(ctx context.Context) (Estimate, error) {
+	ctx, span := tracer.Start(ctx, "ArbitrumEstimator.GasPrices")
+	head, err := trackedHead(ctx, e.tracker, "anchor")
+	if err != nil {
+		endSpan(span, err)
+		return Estimate{}, err
+	}
+	estimate, err := e.estimate(ctx, head)
+	endSpan(span, err)
+	return estimate, err
+}
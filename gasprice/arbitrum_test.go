@@ -0,0 +1,67 @@
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestArbitrumEstimatorGasPrices(t *testing.T) {
+	head := &types.Header{Number: big.NewInt(7)}
+	tracker := newTrackerMock(head)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	estimator, err := NewArbitrumEstimator(ctx, tracker, nil, 3)
+	if err != nil {
+		t.Fatal("could not create estimator:", err)
+	}
+	estimator.caller = newCallerMock(estimator.abi, map[string][]interface{}{
+		"gasEstimateComponents": {uint64(300000), uint64(1500), big.NewInt(10_000_000), big.NewInt(30_000_000_000)},
+	})
+
+	estimate, err := estimator.GasPrices(ctx)
+	if err != nil {
+		t.Fatal("GasPrices returned error:", err)
+	}
+	if estimate.Block.Hash != head.Hash() {
+		t.Error("GasPrices returned wrong block")
+	}
+	if len(estimate.Prices) != 3 {
+		t.Fatal("GasPrices returned wrong number of prices")
+	}
+	for _, price := range estimate.Prices {
+		if price.Cmp(big.NewInt(10_000_000)) != 0 {
+			t.Error("GasPrices did not return the l2 base fee")
+		}
+	}
+	if estimate.L1 == nil || estimate.L1.Gas != 1500 || estimate.L1.L1BaseFee.Cmp(big.NewInt(30_000_000_000)) != 0 {
+		t.Error("GasPrices returned wrong l1 component")
+	}
+	// the l1 gas is charged at the l2 base fee
+	if estimate.L1.Cost.Cmp(big.NewInt(15_000_000_000)) != 0 {
+		t.Errorf("GasPrices returned l1 cost %d but expected 15000000000", estimate.L1.Cost)
+	}
+
+	estimator.caller = newCallerMock(estimator.abi, nil)
+	if _, err := estimator.GasPrices(ctx); err != nil {
+		t.Error("GasPrices should be returned from cache:", err)
+	}
+
+	// an estimate of an older head does not replace the newer one
+	estimator.caller = newCallerMock(estimator.abi, map[string][]interface{}{
+		"gasEstimateComponents": {uint64(300000), uint64(1500), big.NewInt(20_000_000), big.NewInt(30_000_000_000)},
+	})
+	old := &types.Header{Number: big.NewInt(6)}
+	estimate, err = estimator.estimate(ctx, old)
+	if err != nil {
+		t.Fatal("estimate returned error:", err)
+	}
+	if estimate.Block.Hash != old.Hash() {
+		t.Error("estimate returned wrong block")
+	}
+	if estimator.cached(head) == nil {
+		t.Error("estimate replaced the estimate of a newer head")
+	}
+}
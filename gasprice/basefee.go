@@ -0,0 +1,166 @@
+package gasprice
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BaseFeeParams is the EIP-1559 parameters of a block. MinBaseFee is the
+// lowest base fee of OP Stack chains since Jovian and nil elsewhere.
+type BaseFeeParams struct {
+	Denominator uint64
+	Elasticity  uint64
+	MinBaseFee  *big.Int
+}
+
+// BaseFeeFork is the EIP-1559 parameters of the blocks from the time of a
+// fork.
+type BaseFeeFork struct {
+	Time uint64
+	BaseFeeParams
+}
+
+// BaseFeeConfig is the EIP-1559 parameters of a chain ordered by their fork
+// times. OP Stack chains set them in the extra data of the blocks since
+// Holocene, which takes precedence over the forks.
+type BaseFeeConfig struct {
+	Forks    []BaseFeeFork
+	Optimism bool
+}
+
+var EthereumBaseFeeConfig = BaseFeeConfig{[]BaseFeeFork{{0, BaseFeeParams{8, 2, nil}}}, false}
+
+// OptimismCanyonTime is when OP Mainnet and Base activated Canyon.
+const OptimismCanyonTime = 1704992401
+
+// OptimismBaseFeeConfig returns the config of an OP Stack chain that
+// activated Canyon at the time.
+func OptimismBaseFeeConfig(canyon uint64) BaseFeeConfig {
+	return BaseFeeConfig{
+		[]BaseFeeFork{
+			{0, BaseFeeParams{50, 6, nil}},
+			{canyon, BaseFeeParams{250, 6, nil}},
+		},
+		true,
+	}
+}
+
+// holoceneParams decodes the parameters that OP Stack chains set in the extra
+// data since Holocene, in version 0, and Jovian, in version 1 with the
+// minimum base fee. It returns false if the extra data holds none of them.
+func holoceneParams(extra []byte) (BaseFeeParams, bool) {
+	var params BaseFeeParams
+	switch {
+	case len(extra) == 9 && extra[0] == 0:
+	case len(extra) == 17 && extra[0] == 1:
+		params.MinBaseFee = new(big.Int).SetUint64(binary.BigEndian.Uint64(extra[9:]))
+	default:
+		return BaseFeeParams{}, false
+	}
+	params.Denominator = uint64(binary.BigEndian.Uint32(extra[1:5]))
+	params.Elasticity = uint64(binary.BigEndian.Uint32(extra[5:9]))
+	return params, true
+}
+
+// params returns the parameters of the block after head, whose fork is the
+// one of the next second as the next block comes at least a second later.
+func (c BaseFeeConfig) params(head *types.Header) BaseFeeParams {
+	var params BaseFeeParams
+	for _, fork := range c.Forks {
+		if fork.Time <= head.Time+1 {
+			params = fork.BaseFeeParams
+		}
+	}
+	if !c.Optimism {
+		return params
+	}
+	holocene, ok := holoceneParams(head.Extra)
+	if !ok {
+		return params
+	}
+	// zero parameters keep the ones of Canyon
+	if holocene.Denominator == 0 && holocene.Elasticity == 0 {
+		holocene.Denominator = params.Denominator
+		holocene.Elasticity = params.Elasticity
+	}
+	return holocene
+}
+
+// nextBaseFee projects the base fee of the block after head following
+// EIP-1559 with the parameters of the chain. It returns nil if head has no
+// base fee.
+func (c BaseFeeConfig) nextBaseFee(head *types.Header) *big.Int {
+	if head.BaseFee == nil {
+		return nil
+	}
+	params := c.params(head)
+	gasUsed := head.GasUsed
+	// since Jovian the blob gas used of OP Stack blocks is their data
+	// availability footprint, which counts when it is larger
+	if c.Optimism && params.MinBaseFee != nil && head.BlobGasUsed != nil && *head.BlobGasUsed > gasUsed {
+		gasUsed = *head.BlobGasUsed
+	}
+	return params.next(head.BaseFee, head.GasLimit, gasUsed)
+}
+
+// next returns the base fee of the block after the one with the base fee, gas
+// limit and gas used.
+func (p BaseFeeParams) next(baseFee *big.Int, gasLimit uint64, gasUsed uint64) *big.Int {
+	fee := p.change(baseFee, gasLimit, gasUsed)
+	if p.MinBaseFee != nil && fee.Cmp(p.MinBaseFee) < 0 {
+		fee.Set(p.MinBaseFee)
+	}
+	return fee
+}
+
+func (p BaseFeeParams) change(baseFee *big.Int, gasLimit uint64, gasUsed uint64) *big.Int {
+	if p.Elasticity == 0 || p.Denominator == 0 {
+		return new(big.Int).Set(baseFee)
+	}
+	target := gasLimit / p.Elasticity
+	if target == 0 || gasUsed == target {
+		return new(big.Int).Set(baseFee)
+	}
+
+	var gasDelta uint64
+	if gasUsed > target {
+		gasDelta = gasUsed - target
+	} else {
+		gasDelta = target - gasUsed
+	}
+	delta := new(big.Int).SetUint64(gasDelta)
+	delta.Mul(delta, baseFee)
+	delta.Div(delta, new(big.Int).SetUint64(target))
+	delta.Div(delta, new(big.Int).SetUint64(p.Denominator))
+
+	if gasUsed > target {
+		if delta.Sign() == 0 {
+			delta.SetInt64(1)
+		}
+		return delta.Add(baseFee, delta)
+	}
+	fee := delta.Sub(baseFee, delta)
+	if fee.Sign() < 0 {
+		fee.SetInt64(0)
+	}
+	return fee
+}
+
+// BaseFeeBounds returns the lowest and highest base fees of each of the
+// blocks following the one with the base fee, reached if all of them are
+// empty or full.
+func (p BaseFeeParams) BaseFeeBounds(baseFee *big.Int, blocks int) ([]*big.Int, []*big.Int) {
+	lows := make([]*big.Int, blocks)
+	highs := make([]*big.Int, blocks)
+	low := new(big.Int).Set(baseFee)
+	high := new(big.Int).Set(baseFee)
+	for i := 0; i < blocks; i++ {
+		low = p.next(low, p.Elasticity, 0)
+		high = p.next(high, p.Elasticity, p.Elasticity)
+		lows[i] = low
+		highs[i] = high
+	}
+	return lows, highs
+}
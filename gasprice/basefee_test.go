@@ -0,0 +1,149 @@
+package gasprice
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestNextBaseFee(t *testing.T) {
+	tests := []struct {
+		header   *types.Header
+		expected *big.Int
+	}{
+		{
+			&types.Header{GasLimit: 30_000_000, GasUsed: 15_000_000},
+			nil,
+		},
+		{
+			&types.Header{BaseFee: big.NewInt(1_000_000_000), GasLimit: 30_000_000, GasUsed: 15_000_000},
+			big.NewInt(1_000_000_000),
+		},
+		{
+			&types.Header{BaseFee: big.NewInt(1_000_000_000), GasLimit: 30_000_000, GasUsed: 30_000_000},
+			big.NewInt(1_125_000_000),
+		},
+		{
+			&types.Header{BaseFee: big.NewInt(1_000_000_000), GasLimit: 30_000_000, GasUsed: 0},
+			big.NewInt(875_000_000),
+		},
+		{
+			&types.Header{BaseFee: big.NewInt(7), GasLimit: 30_000_000, GasUsed: 15_000_001},
+			big.NewInt(8),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			fee := EthereumBaseFeeConfig.nextBaseFee(test.header)
+			if test.expected == nil {
+				if fee != nil {
+					t.Errorf("nextBaseFee returned %d for a legacy header", fee)
+				}
+				return
+			}
+			if fee == nil || fee.Cmp(test.expected) != 0 {
+				t.Errorf("nextBaseFee returned %d but expected %d", fee, test.expected)
+			}
+		})
+	}
+}
+
+func holoceneExtra(version byte, denominator uint32, elasticity uint32, minBaseFee uint64) []byte {
+	extra := []byte{version}
+	extra = binary.BigEndian.AppendUint32(extra, denominator)
+	extra = binary.BigEndian.AppendUint32(extra, elasticity)
+	if version == 1 {
+		extra = binary.BigEndian.AppendUint64(extra, minBaseFee)
+	}
+	return extra
+}
+
+func TestOptimismNextBaseFee(t *testing.T) {
+	const canyon = 1000
+	config := OptimismBaseFeeConfig(canyon)
+	var footprint uint64 = 30_000_000
+	header := func(time uint64, gasUsed uint64, extra []byte) *types.Header {
+		return &types.Header{
+			Time:        time,
+			BaseFee:     big.NewInt(1_000_000_000),
+			GasLimit:    30_000_000,
+			GasUsed:     gasUsed,
+			BlobGasUsed: &footprint,
+			Extra:       extra,
+		}
+	}
+
+	tests := []struct {
+		header   *types.Header
+		expected int64
+	}{
+		// the target is a sixth of the gas limit and moves 50 times slower
+		{header(canyon-10, 30_000_000, nil), 1_100_000_000},
+		{header(canyon-10, 0, nil), 980_000_000},
+		// canyon moves 250 times slower, from its first block
+		{header(canyon-1, 30_000_000, nil), 1_020_000_000},
+		{header(canyon, 0, nil), 996_000_000},
+		// holocene sets them in the extra data, or keeps the ones of canyon
+		{header(canyon, 30_000_000, holoceneExtra(0, 100, 3, 0)), 1_020_000_000},
+		{header(canyon, 0, holoceneExtra(0, 100, 3, 0)), 990_000_000},
+		{header(canyon, 30_000_000, holoceneExtra(0, 0, 0, 0)), 1_020_000_000},
+		{header(canyon, 5_000_000, holoceneExtra(0, 250, 6, 0)), 1_000_000_000},
+		// jovian adds a minimum base fee and counts the data footprint
+		{header(canyon, 30_000_000, holoceneExtra(1, 250, 6, 2_000_000_000)), 2_000_000_000},
+		{header(canyon, 5_000_000, holoceneExtra(1, 250, 6, 1)), 1_020_000_000},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			fee := config.nextBaseFee(test.header)
+			if fee == nil || fee.Int64() != test.expected {
+				t.Errorf("nextBaseFee returned %d but expected %d", fee, test.expected)
+			}
+		})
+	}
+
+	// other chains do not read the extra data
+	fee := EthereumBaseFeeConfig.nextBaseFee(header(canyon, 30_000_000, holoceneExtra(0, 100, 3, 0)))
+	if fee.Int64() != 1_125_000_000 {
+		t.Errorf("nextBaseFee returned %d but expected 1125000000", fee)
+	}
+}
+
+func TestBaseFeeBounds(t *testing.T) {
+	tests := []struct {
+		params        BaseFeeParams
+		expectedLows  []int64
+		expectedHighs []int64
+	}{
+		{
+			BaseFeeParams{8, 2, nil},
+			[]int64{875_000_000, 765_625_000},
+			[]int64{1_125_000_000, 1_265_625_000},
+		},
+		{
+			BaseFeeParams{250, 6, nil},
+			[]int64{996_000_000, 992_016_000},
+			[]int64{1_020_000_000, 1_040_400_000},
+		},
+		{
+			BaseFeeParams{250, 6, big.NewInt(995_000_000)},
+			[]int64{996_000_000, 995_000_000},
+			[]int64{1_020_000_000, 1_040_400_000},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			lows, highs := test.params.BaseFeeBounds(big.NewInt(1_000_000_000), 2)
+			for j := range test.expectedLows {
+				if lows[j].Int64() != test.expectedLows[j] || highs[j].Int64() != test.expectedHighs[j] {
+					t.Errorf("bounds of block %d are %d and %d", j, lows[j], highs[j])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package gasprice
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var ErrBadBlobSchedule = errors.New("blob schedule is invalid")
+
+// BlobConfig is the blob parameters of the blocks from the time of a fork.
+// BaseCost is the blob base cost of EIP-7918, which is zero before Osaka.
+type BlobConfig struct {
+	Time           uint64 `json:"time"`
+	Target         uint64 `json:"target"`
+	Max            uint64 `json:"max"`
+	UpdateFraction uint64 `json:"baseFeeUpdateFraction"`
+	BaseCost       uint64 `json:"baseCost"`
+}
+
+// BlobSchedule is the blob parameters of a chain ordered by their fork times.
+type BlobSchedule []BlobConfig
+
+var MainnetBlobSchedule = BlobSchedule{
+	{1710338135, 3, 6, 3338477, 0},       // Cancun
+	{1746612311, 6, 9, 5007716, 0},       // Prague
+	{1764798551, 6, 9, 5007716, 8192},    // Osaka
+	{1765290071, 10, 15, 8346193, 8192},  // BPO1
+	{1767747671, 14, 21, 11684671, 8192}, // BPO2
+}
+
+var SepoliaBlobSchedule = BlobSchedule{
+	{1706655072, 3, 6, 3338477, 0},       // Cancun
+	{1741159776, 6, 9, 5007716, 0},       // Prague
+	{1760427360, 6, 9, 5007716, 8192},    // Osaka
+	{1761017184, 10, 15, 8346193, 8192},  // BPO1
+	{1761607008, 14, 21, 11684671, 8192}, // BPO2
+}
+
+var blobSchedules = map[uint64]BlobSchedule{
+	1:        MainnetBlobSchedule,
+	11155111: SepoliaBlobSchedule,
+}
+
+// ChainBlobSchedule returns the blob schedule of a known chain, or nil.
+func ChainBlobSchedule(chainID *big.Int) BlobSchedule {
+	if !chainID.IsUint64() {
+		return nil
+	}
+	return blobSchedules[chainID.Uint64()]
+}
+
+// LoadBlobSchedule reads a blob schedule from a json file.
+func LoadBlobSchedule(path string) (BlobSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schedule BlobSchedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, err
+	}
+	if len(schedule) == 0 {
+		return nil, ErrBadBlobSchedule
+	}
+	for i, config := range schedule {
+		if config.Target == 0 || config.Max < config.Target || config.UpdateFraction == 0 {
+			return nil, ErrBadBlobSchedule
+		}
+		if i > 0 && config.Time <= schedule[i-1].Time {
+			return nil, ErrBadBlobSchedule
+		}
+	}
+	return schedule, nil
+}
+
+// at returns the blob parameters of a block at the time, or nil if it
+// predates the schedule.
+func (s BlobSchedule) at(time uint64) *BlobConfig {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i].Time <= time {
+			return &s[i]
+		}
+	}
+	return nil
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) as
+// specified by EIP-4844.
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	output := new(big.Int)
+	accum := new(big.Int).Mul(factor, denominator)
+	for i := int64(1); accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(i))
+	}
+	return output.Div(output, denominator)
+}
+
+func (c *BlobConfig) blobFee(excess uint64) *big.Int {
+	return fakeExponential(
+		big.NewInt(params.BlobTxMinBlobGasprice),
+		new(big.Int).SetUint64(excess),
+		new(big.Int).SetUint64(c.UpdateFraction),
+	)
+}
+
+// excessBlobGas computes the excess blob gas of the block after parent, which
+// is priced at least at the reserve price of EIP-7918 once it is enabled.
+func (c *BlobConfig) excessBlobGas(parent *types.Header) uint64 {
+	excess := *parent.ExcessBlobGas + *parent.BlobGasUsed
+	target := c.Target * params.BlobTxBlobGasPerBlob
+	if excess < target {
+		return 0
+	}
+	if c.BaseCost != 0 && parent.BaseFee != nil {
+		reserve := new(big.Int).Mul(parent.BaseFee, new(big.Int).SetUint64(c.BaseCost))
+		price := c.blobFee(*parent.ExcessBlobGas)
+		price.Mul(price, big.NewInt(params.BlobTxBlobGasPerBlob))
+		if reserve.Cmp(price) > 0 {
+			return *parent.ExcessBlobGas + *parent.BlobGasUsed*(c.Max-c.Target)/c.Max
+		}
+	}
+	return excess - target
+}
+
+// nextBlobBaseFee projects the blob base fee of the block after head with the
+// parameters of the next second, as the next block comes at least a second
+// later. It returns nil if head predates EIP-4844 or the schedule.
+func (s BlobSchedule) nextBlobBaseFee(head *types.Header) *big.Int {
+	if head.ExcessBlobGas == nil || head.BlobGasUsed == nil {
+		return nil
+	}
+	config := s.at(head.Time + 1)
+	if config == nil {
+		return nil
+	}
+	return config.blobFee(config.excessBlobGas(head))
+}
+
+// blobBandEstimates averages the blob fee caps within each target, never
+// going below the projected blob base fee since such caps could not be
+// included in the next block. Without any blob transaction every target is
+// the projected blob base fee. It returns nil if head predates EIP-4844 or
+// the schedule.
+func (s BlobSchedule) blobBandEstimates(head *types.Header, caps bigIntHeap, targets []Target) []*big.Int {
+	baseFee := s.nextBlobBaseFee(head)
+	if baseFee == nil {
+		return nil
+	}
+
+	estimates := make([]*big.Int, len(targets))
+	if len(caps) == 0 {
+		for i := range estimates {
+			estimates[i] = new(big.Int).Set(baseFee)
+		}
+		return estimates
+	}
+
+	sort.Sort(caps)
+	estimates = bandEstimates(caps, targets)
+	for i := range estimates {
+		if estimates[i].Cmp(baseFee) == -1 {
+			estimates[i].Set(baseFee)
+		}
+	}
+	return estimates
+}
@@ -0,0 +1,152 @@
+package gasprice
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// gethNextBlobFee is the blob base fee of the block after parent as
+// go-ethereum computes it on mainnet.
+func gethNextBlobFee(parent *types.Header) *big.Int {
+	excess := eip4844.CalcExcessBlobGas(params.MainnetChainConfig, parent, parent.Time+1)
+	return eip4844.CalcBlobFee(params.MainnetChainConfig, &types.Header{Time: parent.Time + 1, ExcessBlobGas: &excess})
+}
+
+func TestBlobBandEstimates(t *testing.T) {
+	var zero uint64
+	var full uint64 = MainnetBlobSchedule[0].Max * params.BlobTxBlobGasPerBlob
+	var excess uint64 = 5 * MainnetBlobSchedule[0].UpdateFraction
+	cancun := MainnetBlobSchedule[0].Time
+	targets := []Target{{0, 0.5}, {0.5, 1}}
+
+	tests := []struct {
+		header   *types.Header
+		caps     bigIntHeap
+		expected []*big.Int
+	}{
+		{
+			&types.Header{Time: cancun},
+			bigIntHeap{big.NewInt(1000)},
+			nil,
+		},
+		{
+			&types.Header{Time: cancun - 2, ExcessBlobGas: &zero, BlobGasUsed: &zero},
+			bigIntHeap{big.NewInt(1000)},
+			nil,
+		},
+		{
+			&types.Header{Time: cancun, ExcessBlobGas: &zero, BlobGasUsed: &zero},
+			bigIntHeap{big.NewInt(3000), big.NewInt(1000)},
+			[]*big.Int{big.NewInt(1000), big.NewInt(3000)},
+		},
+		{
+			&types.Header{Time: cancun, ExcessBlobGas: &zero, BlobGasUsed: &zero},
+			bigIntHeap{},
+			[]*big.Int{big.NewInt(1), big.NewInt(1)},
+		},
+		{
+			&types.Header{Time: cancun, ExcessBlobGas: &excess, BlobGasUsed: &full},
+			bigIntHeap{big.NewInt(3000), big.NewInt(100)},
+			[]*big.Int{gethNextBlobFee(&types.Header{Time: cancun, ExcessBlobGas: &excess, BlobGasUsed: &full}), big.NewInt(3000)},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			estimates := MainnetBlobSchedule.blobBandEstimates(test.header, test.caps, targets)
+			if len(estimates) != len(test.expected) {
+				t.Fatalf("blobBandEstimates returned %d prices but expected %d", len(estimates), len(test.expected))
+			}
+			for j := range estimates {
+				if estimates[j].Cmp(test.expected[j]) != 0 {
+					t.Errorf("blobBandEstimates returned %d but expected %d", estimates[j], test.expected[j])
+				}
+			}
+		})
+	}
+}
+
+func TestNextBlobBaseFee(t *testing.T) {
+	var excess uint64 = 33384770
+	var used uint64 = 6 * params.BlobTxBlobGasPerBlob
+	header := func(time uint64, baseFee int64) *types.Header {
+		return &types.Header{Time: time, BaseFee: big.NewInt(baseFee), ExcessBlobGas: &excess, BlobGasUsed: &used}
+	}
+
+	tests := []struct {
+		header   *types.Header
+		expected int64
+	}{
+		// cancun targets 3 blobs
+		{header(MainnetBlobSchedule[0].Time, 1e10), 24779},
+		// prague targets 6 blobs with a slower update fraction
+		{header(MainnetBlobSchedule[1].Time, 1e10), 785},
+		// osaka prices blobs at least at the reserve price of the base fee
+		{header(MainnetBlobSchedule[2].Time, 1e10), 828},
+		{header(MainnetBlobSchedule[2].Time, 1), 785},
+		// the next block of the last prague block is an osaka one
+		{header(MainnetBlobSchedule[2].Time-1, 1e10), 828},
+		// bpo2 targets 14 blobs with a faster update fraction
+		{header(MainnetBlobSchedule[4].Time, 1), 15},
+		{header(MainnetBlobSchedule[4].Time, 1e10), 17},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			fee := MainnetBlobSchedule.nextBlobBaseFee(test.header)
+			if fee == nil || fee.Int64() != test.expected {
+				t.Errorf("nextBlobBaseFee returned %d but expected %d", fee, test.expected)
+			}
+		})
+	}
+}
+
+func TestLoadBlobSchedule(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected BlobSchedule
+	}{
+		{
+			`[{"time": 0, "target": 3, "max": 6, "baseFeeUpdateFraction": 3338477}, {"time": 100, "target": 6, "max": 9, "baseFeeUpdateFraction": 5007716, "baseCost": 8192}]`,
+			BlobSchedule{{0, 3, 6, 3338477, 0}, {100, 6, 9, 5007716, 8192}},
+		},
+		{`[]`, nil},
+		{`[{"time": 0, "target": 6, "max": 3, "baseFeeUpdateFraction": 3338477}]`, nil},
+		{`[{"time": 0, "target": 3, "max": 6}]`, nil},
+		{`[{"time": 100, "target": 3, "max": 6, "baseFeeUpdateFraction": 1}, {"time": 100, "target": 3, "max": 6, "baseFeeUpdateFraction": 1}]`, nil},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "blobs.json")
+			if err := os.WriteFile(path, []byte(test.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			schedule, err := LoadBlobSchedule(path)
+			if test.expected == nil {
+				if err != ErrBadBlobSchedule {
+					t.Fatalf("LoadBlobSchedule should fail but returned %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("LoadBlobSchedule returned error:", err)
+			}
+			if len(schedule) != len(test.expected) {
+				t.Fatalf("LoadBlobSchedule returned %d configs but expected %d", len(schedule), len(test.expected))
+			}
+			for j := range schedule {
+				if schedule[j] != test.expected[j] {
+					t.Errorf("LoadBlobSchedule returned %v but expected %v", schedule[j], test.expected[j])
+				}
+			}
+		})
+	}
+}
@@ -3,22 +3,26 @@ package gasprice
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"math/big"
-	"sort"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var ErrBadTargets = errors.New("targets is invalid")
+var ErrBadPercentiles = errors.New("percentiles is invalid")
 var ErrNoSample = errors.New("no sample to estimate")
 
 type Provider interface {
+	ChainID(ctx context.Context) (*big.Int, error)
 	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
 	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
 }
 
@@ -36,59 +40,166 @@ type Target struct {
 	End   float64
 }
 
+type Block struct {
+	Number uint64
+	Hash   common.Hash
+	Time   uint64
+}
+
+func newBlock(header *types.Header) Block {
+	return Block{header.Number.Uint64(), header.Hash(), header.Time}
+}
+
+// latencySince returns how long ago the header arrived at the tracker, or zero
+// if the tracker no longer remembers it.
+func latencySince(tracker Tracker, header *types.Header) time.Duration {
+	arrived := tracker.arrival(header.Hash())
+	if arrived.IsZero() {
+		return 0
+	}
+	return time.Since(arrived)
+}
+
+// Estimate is the gas prices of a block. BaseFee is the base fee of the block
+// itself and Latency is how long the estimate took from the arrival of the
+// block at the tracker. BaseFeeParams is the EIP-1559 parameters of the next
+// block, nil if they are not known.
+type Estimate struct {
+	Block         Block
+	Latest        Block
+	Prices        []*big.Int
+	BaseFee       *big.Int
+	NextBaseFee   *big.Int
+	BaseFeeParams *BaseFeeParams
+	BlobBaseFee   *big.Int
+	BlobPrices    []*big.Int
+	L1            *L1Component
+	Latency       time.Duration
+	sample        *sortedSample
+}
+
+func cloneBigInt(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
+func (e *Estimate) clone() Estimate {
+	var blobBaseFee *big.Int
+	var blobPrices []*big.Int
+	if e.BlobBaseFee != nil {
+		blobBaseFee = new(big.Int).Set(e.BlobBaseFee)
+		blobPrices = clonePrices(e.BlobPrices)
+	}
+	var baseFeeParams *BaseFeeParams
+	if e.BaseFeeParams != nil {
+		params := *e.BaseFeeParams
+		params.MinBaseFee = cloneBigInt(params.MinBaseFee)
+		baseFeeParams = &params
+	}
+	var l1 *L1Component
+	if e.L1 != nil {
+		l1 = &L1Component{new(big.Int).Set(e.L1.L1BaseFee), e.L1.Gas, new(big.Int).Set(e.L1.Cost)}
+	}
+	return Estimate{
+		e.Block,
+		e.Latest,
+		clonePrices(e.Prices),
+		cloneBigInt(e.BaseFee),
+		cloneBigInt(e.NextBaseFee),
+		baseFeeParams,
+		blobBaseFee,
+		blobPrices,
+		l1,
+		e.Latency,
+		e.sample,
+	}
+}
+
 type gasPricesResult struct {
-	prices []*big.Int
-	err    error
+	estimate Estimate
+	err      error
 }
 
 type Estimator struct {
-	tracker    Tracker
-	sampler    Sampler
-	skip       int
-	history    int
-	targets    []Target
-	lastHead   common.Hash
-	lastPrices []*big.Int
-	chans      []chan<- gasPricesResult
-	lock       sync.RWMutex
+	tracker      Tracker
+	anchor       Tracker
+	sampler      Sampler
+	skip         int
+	history      int
+	targets      []Target
+	baseFees     BaseFeeConfig
+	blobs        BlobSchedule
+	lastHead     common.Hash
+	lastEstimate *Estimate
+	chans        []chan<- gasPricesResult
+	lock         sync.RWMutex
+	estimateFeed
 }
 
+// NewEstimator creates an estimator that leaves out the blob prices when blobs
+// is nil.
 func NewEstimator(
 	ctx context.Context,
 	tracker Tracker,
+	anchor Tracker,
 	sampler Sampler,
 	skip int,
 	history int,
 	targets []Target,
+	baseFees BaseFeeConfig,
+	blobs BlobSchedule,
 ) (*Estimator, error) {
-	for _, t := range targets {
-		if t.Start < 0 || t.End <= t.Start || t.End > 1 {
-			return nil, ErrBadTargets
-		}
+	if err := validateTargets(targets); err != nil {
+		return nil, err
 	}
 
 	e := &Estimator{
 		tracker,
+		anchor,
 		sampler,
 		skip,
 		history,
 		targets,
+		baseFees,
+		blobs,
 		zeroHash,
 		nil,
 		nil,
 		sync.RWMutex{},
+		newEstimateFeed(),
 	}
 	go e.listen(ctx)
 
 	return e, nil
 }
 
+func validateTargets(targets []Target) error {
+	for _, t := range targets {
+		if t.Start < 0 || t.End <= t.Start || t.End > 1 {
+			return ErrBadTargets
+		}
+	}
+	return nil
+}
+
 func (e *Estimator) listen(ctx context.Context) {
-	subscription := e.tracker.subscribe()
+	subscription := e.anchor.subscribe()
 	for {
 		select {
-		case <-subscription.ch:
-			e.GasPrices(ctx)
+		case head := <-subscription.ch:
+			select {
+			case r := <-e.asyncGasPrices(ctx, head):
+				if r.err != nil {
+					slog.Error("could not estimate gas prices", "number", head.Number, "hash", head.Hash(), "err", r.err)
+					continue
+				}
+				slog.Info("estimated gas prices", "number", head.Number, "hash", head.Hash(), "latency", r.estimate.Latency)
+			case <-ctx.Done():
+				subscription.unsubscribe()
+				return
+			}
 		case <-ctx.Done():
 			subscription.unsubscribe()
 			return
@@ -96,28 +207,47 @@ func (e *Estimator) listen(ctx context.Context) {
 	}
 }
 
-func (e *Estimator) estimate(ctx context.Context, head common.Hash) ([]*big.Int, error) {
+func (e *Estimator) estimate(ctx context.Context, head *types.Header) (*sortedSample, []*big.Int, error) {
+	e.sampler.prefetch(ctx, head, e.history)
+
 	prices := make(bigIntHeap, 0)
-	tip := head
+	gas := make([]uint64, 0)
+	blobPrices := make(bigIntHeap, 0)
+	weighted := true
+	tip := head.Hash()
 	for i := 0; i < e.skip; i++ {
 
 	}
 	for i := 0; i < e.history; i++ {
 		sample, err := e.sampler.sample(ctx, tip)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		prices = append(prices, sample.prices...)
+		if len(sample.gas) == len(sample.prices) {
+			gas = append(gas, sample.gas...)
+		} else {
+			weighted = false
+		}
+		blobPrices = append(blobPrices, sample.blobPrices...)
 		tip = sample.header.ParentHash
 	}
 
 	nprices := len(prices)
 	if nprices == 0 {
-		return nil, ErrNoSample
+		return nil, nil, ErrNoSample
+	}
+	if !weighted {
+		gas = nil
 	}
-	sort.Sort(prices)
-	estimates := make([]*big.Int, len(e.targets))
-	for i, t := range e.targets {
+	return newSortedSample(prices, gas), e.blobs.blobBandEstimates(head, blobPrices, e.targets), nil
+}
+
+// bandEstimates averages the sorted prices within each target.
+func bandEstimates(prices bigIntHeap, targets []Target) []*big.Int {
+	nprices := len(prices)
+	estimates := make([]*big.Int, len(targets))
+	for i, t := range targets {
 		start := int(t.Start * float64(nprices))
 		end := int(t.End * float64(nprices))
 		if end-start == 0 {
@@ -130,81 +260,181 @@ func (e *Estimator) estimate(ctx context.Context, head common.Hash) ([]*big.Int,
 		n := big.NewInt(int64(end - start))
 		estimates[i] = new(big.Int).Div(sum, n)
 	}
+	return estimates
+}
+
+type weightedPrices struct {
+	prices []*big.Int
+	gas    []uint64
+}
+
+func (w weightedPrices) Len() int { return len(w.prices) }
+
+func (w weightedPrices) Less(i, j int) bool { return w.prices[i].Cmp(w.prices[j]) == -1 }
+
+func (w weightedPrices) Swap(i, j int) {
+	w.prices[i], w.prices[j] = w.prices[j], w.prices[i]
+	w.gas[i], w.gas[j] = w.gas[j], w.gas[i]
+}
+
+// weightedEstimates averages the sorted prices of each target weighted by the
+// gas they used, where the targets are fractions of the total gas rather than
+// of the number of transactions. It returns nil if no gas was used at all.
+func weightedEstimates(prices []*big.Int, gas []uint64, targets []Target) []*big.Int {
+	total := totalGas(gas)
+	if total == 0 {
+		return nil
+	}
 
-	return estimates, nil
+	estimates := make([]*big.Int, len(targets))
+	for i, t := range targets {
+		start := uint64(t.Start * float64(total))
+		end := uint64(t.End * float64(total))
+		if end-start == 0 {
+			end += 1
+		}
+		sum := new(big.Int)
+		var weight uint64
+		var used uint64
+		for j, price := range prices {
+			from, to := used, used+gas[j]
+			used = to
+			if from < start {
+				from = start
+			}
+			if to > end {
+				to = end
+			}
+			if from >= to {
+				continue
+			}
+			overlap := new(big.Int).SetUint64(to - from)
+			sum.Add(sum, overlap.Mul(overlap, price))
+			weight += to - from
+		}
+		estimates[i] = sum.Div(sum, new(big.Int).SetUint64(weight))
+	}
+
+	return estimates
 }
 
-func (e *Estimator) broadcastGasPrices(head common.Hash) {
+// broadcastGasPrices estimates the gas prices of the head for everyone
+// waiting on them, in a span linked to the one of whoever asked first.
+func (e *Estimator) broadcastGasPrices(head *types.Header, link trace.Link) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	prices, err := e.estimate(ctx, head)
+	ctx, span := tracer.Start(ctx, "Estimator.estimate", headerAttributes(head), trace.WithLinks(link))
+	sample, blobPrices, err := e.estimate(ctx, head)
+	endSpan(span, err)
 	e.lock.Lock()
 	defer e.lock.Unlock()
 	if err != nil {
 		for _, ch := range e.chans {
-			ch <- gasPricesResult{nil, err}
+			ch <- gasPricesResult{Estimate{}, err}
 			close(ch)
 		}
 		e.chans = nil
 		return
 	}
 
-	if e.lastHead != head {
+	if e.lastHead != head.Hash() {
 		return
 	}
 
-	e.lastPrices = prices
+	block := newBlock(head)
+	var baseFeeParams *BaseFeeParams
+	if head.BaseFee != nil {
+		params := e.baseFees.params(head)
+		baseFeeParams = &params
+	}
+	e.lastEstimate = &Estimate{
+		Block:         block,
+		Latest:        block,
+		Prices:        sample.bands(e.targets),
+		BaseFee:       cloneBigInt(head.BaseFee),
+		NextBaseFee:   e.baseFees.nextBaseFee(head),
+		BaseFeeParams: baseFeeParams,
+		BlobBaseFee:   e.blobs.nextBlobBaseFee(head),
+		BlobPrices:    blobPrices,
+		Latency:       latencySince(e.anchor, head),
+		sample:        sample,
+	}
+	e.publish(e.lastEstimate)
 	for _, ch := range e.chans {
-		ch <- gasPricesResult{clonePrices(prices), nil}
+		ch <- gasPricesResult{e.lastEstimate.clone(), nil}
 		close(ch)
 	}
 	e.chans = nil
 }
 
-func (e *Estimator) asyncGasPrices(head common.Hash) <-chan gasPricesResult {
+func (e *Estimator) asyncGasPrices(ctx context.Context, head *types.Header) <-chan gasPricesResult {
 	ch := make(chan gasPricesResult, 1)
 	e.lock.Lock()
 	defer e.lock.Unlock()
 	lastHead := e.lastHead
-	lastPrices := e.lastPrices
-	if lastHead == head && lastPrices != nil {
-		ch <- gasPricesResult{clonePrices(lastPrices), nil}
+	lastEstimate := e.lastEstimate
+	if lastHead == head.Hash() && lastEstimate != nil {
+		ch <- gasPricesResult{lastEstimate.clone(), nil}
 		close(ch)
 		return ch
 	}
 
 	chans := append(e.chans, ch)
 	e.chans = chans
-	if lastHead != head {
-		e.lastHead = head
-		e.lastPrices = nil
-		go e.broadcastGasPrices(head)
+	if lastHead != head.Hash() {
+		e.lastHead = head.Hash()
+		e.lastEstimate = nil
+		go e.broadcastGasPrices(head, trace.LinkFromContext(ctx))
 	}
 	return ch
 }
 
-func (e *Estimator) GasPrices(ctx context.Context) ([]*big.Int, error) {
-	head, err := e.tracker.head(ctx)
-	if err != nil {
-		return nil, err
-	}
-
+func (e *Estimator) anchoredGasPrices(ctx context.Context, head *types.Header) (Estimate, error) {
 	e.lock.RLock()
 	lastHead := e.lastHead
-	lastPrices := e.lastPrices
-	if lastPrices != nil {
-		lastPrices = clonePrices(lastPrices)
-	}
+	lastEstimate := e.lastEstimate
 	e.lock.RUnlock()
-	if lastHead == head && lastPrices != nil {
-		return lastPrices, nil
+	if lastHead == head.Hash() && lastEstimate != nil {
+		return lastEstimate.clone(), nil
 	}
 
+	ctx, span := tracer.Start(ctx, "Estimator.wait", headerAttributes(head))
 	select {
-	case r := <-e.asyncGasPrices(head):
-		return r.prices, r.err
+	case r := <-e.asyncGasPrices(ctx, head):
+		endSpan(span, r.err)
+		return r.estimate, r.err
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		endSpan(span, ctx.Err())
+		return Estimate{}, ctx.Err()
+	}
+}
+
+func (e *Estimator) GasPrices(ctx context.Context) (Estimate, error) {
+	ctx, span := tracer.Start(ctx, "Estimator.GasPrices")
+	estimate, err := e.gasPrices(ctx)
+	endSpan(span, err)
+	return estimate, err
+}
+
+func (e *Estimator) gasPrices(ctx context.Context) (Estimate, error) {
+	head, err := trackedHead(ctx, e.anchor, "anchor")
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	latest := head
+	if e.tracker != e.anchor {
+		latest, err = trackedHead(ctx, e.tracker, "latest")
+		if err != nil {
+			return Estimate{}, err
+		}
+	}
+
+	estimate, err := e.anchoredGasPrices(ctx, head)
+	if err != nil {
+		return Estimate{}, err
 	}
+	estimate.Latest = newBlock(latest)
+	return estimate, nil
 }
@@ -15,20 +15,22 @@ import (
 var ErrBlockNotFound = errors.New("could not get block")
 
 type trackerMock struct {
-	lastHead common.Hash
-	subs     map[chan<- struct{}]struct{}
+	lastHead *types.Header
+	subs     map[chan *types.Header]struct{}
 	lock     sync.RWMutex
+	*arrivals
 }
 
-func newTrackerMock(head common.Hash) *trackerMock {
+func newTrackerMock(head *types.Header) *trackerMock {
 	return &trackerMock{
 		head,
-		make(map[chan<- struct{}]struct{}),
+		make(map[chan *types.Header]struct{}),
 		sync.RWMutex{},
+		newArrivals(),
 	}
 }
 
-func (t *trackerMock) head(ctx context.Context) (common.Hash, error) {
+func (t *trackerMock) head(ctx context.Context) (*types.Header, error) {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 	return t.lastHead, nil
@@ -37,7 +39,7 @@ func (t *trackerMock) head(ctx context.Context) (common.Hash, error) {
 func (t *trackerMock) subscribe() trackerSubscription {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	ch := make(chan struct{}, 1)
+	ch := make(chan *types.Header, 1)
 	t.subs[ch] = struct{}{}
 
 	unsubscribe := func() {
@@ -50,16 +52,14 @@ func (t *trackerMock) subscribe() trackerSubscription {
 	return trackerSubscription{ch, unsubscribe}
 }
 
-func (t *trackerMock) changeHead(head common.Hash) {
+func (t *trackerMock) changeHead(head *types.Header) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	if t.lastHead != head {
+	if t.lastHead.Hash() != head.Hash() {
 		t.lastHead = head
+		t.markArrival(head)
 		for sub := range t.subs {
-			select {
-			case sub <- struct{}{}:
-			default:
-			}
+			notify(sub, head)
 		}
 	}
 }
@@ -90,6 +90,8 @@ func (s *samplerMock) sample(ctx context.Context, hash common.Hash) (Sample, err
 	return Sample{}, ErrBlockNotFound
 }
 
+func (s *samplerMock) prefetch(ctx context.Context, head *types.Header, count int) {}
+
 func (s *samplerMock) requestCount() int {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -104,37 +106,51 @@ func (s *samplerMock) resetCount() {
 func TestEstimatorGasPrices(t *testing.T) {
 	samples := make([]Sample, 3)
 	samples[0] = Sample{
-		&types.Header{},
+		&types.Header{Number: big.NewInt(0)},
 		[]*big.Int{big.NewInt(30), big.NewInt(20), big.NewInt(40), big.NewInt(30)},
+		nil,
+		nil,
 	}
 	samples[1] = Sample{
-		&types.Header{ParentHash: samples[0].header.Hash()},
+		&types.Header{ParentHash: samples[0].header.Hash(), Number: big.NewInt(1)},
 		[]*big.Int{big.NewInt(40), big.NewInt(30), big.NewInt(10)},
+		nil,
+		nil,
 	}
 	samples[2] = Sample{
-		&types.Header{ParentHash: samples[1].header.Hash()},
+		&types.Header{ParentHash: samples[1].header.Hash(), Number: big.NewInt(2), Time: 24},
 		[]*big.Int{big.NewInt(35), big.NewInt(25), big.NewInt(45), big.NewInt(35)},
+		nil,
+		nil,
 	}
 	expected := []*big.Int{big.NewInt(21), big.NewInt(38), big.NewInt(31)}
-	tracker := newTrackerMock(samples[2].header.Hash())
+	tracker := newTrackerMock(samples[2].header)
 	sampler := newSamplerMock(samples)
 	estimator := &Estimator{
-		tracker:    tracker,
-		sampler:    sampler,
-		skip:       1,
-		history:    2,
-		targets:    []Target{{0, 0.5}, {0.5, 1}, {0, 1}},
-		lastHead:   zeroHash,
-		lastPrices: nil,
-		chans:      nil,
-		lock:       sync.RWMutex{},
+		tracker:      tracker,
+		anchor:       tracker,
+		sampler:      sampler,
+		skip:         1,
+		history:      2,
+		targets:      []Target{{0, 0.5}, {0.5, 1}, {0, 1}},
+		lastHead:     zeroHash,
+		lastEstimate: nil,
+		chans:        nil,
+		lock:         sync.RWMutex{},
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	results, err := estimator.GasPrices(ctx)
+	estimate, err := estimator.GasPrices(ctx)
 	if err != nil {
 		t.Fatal("GasPrices returned error:", err)
 	}
+	if estimate.Block.Hash != samples[2].header.Hash() {
+		t.Fatal("GasPrices returned wrong block hash")
+	}
+	if estimate.Block.Number != 2 || estimate.Block.Time != 24 {
+		t.Fatal("GasPrices returned wrong block number or time")
+	}
+	results := estimate.Prices
 	if len(expected) != len(results) {
 		t.Fatal("GasPrices returned wrong number of prices")
 	}
@@ -157,29 +173,35 @@ func TestEstimatorGasPrices(t *testing.T) {
 func TestEstimatorListen(t *testing.T) {
 	samples := make([]Sample, 3)
 	samples[0] = Sample{
-		&types.Header{},
+		&types.Header{Number: big.NewInt(0)},
 		[]*big.Int{big.NewInt(30), big.NewInt(20), big.NewInt(40), big.NewInt(30)},
+		nil,
+		nil,
 	}
 	samples[1] = Sample{
-		&types.Header{ParentHash: samples[0].header.Hash()},
+		&types.Header{ParentHash: samples[0].header.Hash(), Number: big.NewInt(1)},
 		[]*big.Int{big.NewInt(40), big.NewInt(30), big.NewInt(10)},
+		nil,
+		nil,
 	}
 	samples[2] = Sample{
-		&types.Header{ParentHash: samples[1].header.Hash()},
+		&types.Header{ParentHash: samples[1].header.Hash(), Number: big.NewInt(2), BaseFee: big.NewInt(1000), GasLimit: 100, GasUsed: 100},
 		[]*big.Int{big.NewInt(35), big.NewInt(25), big.NewInt(45), big.NewInt(35)},
+		nil,
+		nil,
 	}
 	expected := big.NewInt(31)
-	tracker := newTrackerMock(samples[1].header.Hash())
+	tracker := newTrackerMock(samples[1].header)
 	sampler := newSamplerMock(samples)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	estimator, err := NewEstimator(ctx, tracker, sampler, 0, 2, []Target{{0, 1}})
+	estimator, err := NewEstimator(ctx, tracker, tracker, sampler, 0, 2, []Target{{0, 1}}, EthereumBaseFeeConfig, nil)
 	if err != nil {
 		t.Fatal("could not create estimator")
 	}
 
 	<-time.After(100 * time.Millisecond)
-	tracker.changeHead(samples[2].header.Hash())
+	tracker.changeHead(samples[2].header)
 	<-time.After(100 * time.Millisecond)
 
 	estimator.lock.RLock()
@@ -187,10 +209,83 @@ func TestEstimatorListen(t *testing.T) {
 	if estimator.lastHead != samples[2].header.Hash() {
 		t.Fatal("the lastHead did not get updated")
 	}
-	if len(estimator.lastPrices) != 1 {
-		t.Fatal("lastPrices has wrong length")
+	if estimator.lastEstimate == nil || len(estimator.lastEstimate.Prices) != 1 {
+		t.Fatal("lastEstimate has wrong length")
+	}
+	if estimator.lastEstimate.Prices[0].Cmp(expected) != 0 {
+		t.Fatal("lastEstimate has wrong value")
+	}
+	if estimator.lastEstimate.BaseFee.Cmp(big.NewInt(1000)) != 0 || estimator.lastEstimate.NextBaseFee.Cmp(big.NewInt(1125)) != 0 {
+		t.Fatalf("lastEstimate has base fee %v and next base fee %v", estimator.lastEstimate.BaseFee, estimator.lastEstimate.NextBaseFee)
+	}
+	if latency := estimator.lastEstimate.Latency; latency <= 0 || latency >= 100*time.Millisecond {
+		t.Fatalf("lastEstimate has latency %v", latency)
+	}
+}
+
+func TestEstimatorGasPricesAnchor(t *testing.T) {
+	samples := make([]Sample, 3)
+	samples[0] = Sample{
+		&types.Header{Number: big.NewInt(0)},
+		[]*big.Int{big.NewInt(30), big.NewInt(20)},
+		nil,
+		nil,
+	}
+	samples[1] = Sample{
+		&types.Header{ParentHash: samples[0].header.Hash(), Number: big.NewInt(1)},
+		[]*big.Int{big.NewInt(40), big.NewInt(10)},
+		nil,
+		nil,
+	}
+	samples[2] = Sample{
+		&types.Header{ParentHash: samples[1].header.Hash(), Number: big.NewInt(2)},
+		[]*big.Int{big.NewInt(80), big.NewInt(60)},
+		nil,
+		nil,
+	}
+	expected := big.NewInt(25)
+	tracker := newTrackerMock(samples[2].header)
+	anchor := newTrackerMock(samples[1].header)
+	sampler := newSamplerMock(samples)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	estimator, err := NewEstimator(ctx, tracker, anchor, sampler, 0, 2, []Target{{0, 1}}, EthereumBaseFeeConfig, nil)
+	if err != nil {
+		t.Fatal("could not create estimator")
+	}
+
+	estimate, err := estimator.GasPrices(ctx)
+	if err != nil {
+		t.Fatal("GasPrices returned error:", err)
+	}
+	if estimate.Block.Hash != samples[1].header.Hash() {
+		t.Fatal("estimate is not anchored on the anchor head")
+	}
+	if estimate.Latest.Hash != samples[2].header.Hash() {
+		t.Fatal("estimate does not report the latest head")
 	}
-	if estimator.lastPrices[0].Cmp(expected) != 0 {
-		t.Fatal("lastPrice has wrong value")
+	if len(estimate.Prices) != 1 || estimate.Prices[0].Cmp(expected) != 0 {
+		t.Fatal("GasPrices returned wrong prices")
+	}
+}
+
+func TestWeightedEstimates(t *testing.T) {
+	prices := []*big.Int{big.NewInt(10), big.NewInt(20), big.NewInt(30)}
+	gas := []uint64{100, 100, 200}
+	targets := []Target{{0, 0.5}, {0.5, 1}, {0.25, 0.75}}
+	expected := []*big.Int{big.NewInt(15), big.NewInt(30), big.NewInt(25)}
+
+	estimates := weightedEstimates(prices, gas, targets)
+	if len(estimates) != len(expected) {
+		t.Fatal("weightedEstimates returned wrong number of prices")
+	}
+	for i := range expected {
+		if expected[i].Cmp(estimates[i]) != 0 {
+			t.Errorf("weightedEstimates returned %d for target %d but expected %d", estimates[i], i, expected[i])
+		}
+	}
+
+	if weightedEstimates(prices, []uint64{0, 0, 0}, targets) != nil {
+		t.Error("weightedEstimates should return nil when no gas is used")
 	}
 }
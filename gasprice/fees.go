@@ -0,0 +1,30 @@
+package gasprice
+
+import "math/big"
+
+// Fee is the pair of EIP-1559 fee caps for a tier.
+type Fee struct {
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// Fees returns the fee caps of each tier. The tip is what the tier pays above
+// the next base fee, and the fee cap leaves room for the base fee to double
+// so the transaction stays includable for a few full blocks. Without a base
+// fee both caps are the tier price.
+func (e *Estimate) Fees() []Fee {
+	fees := make([]Fee, len(e.Prices))
+	for i, price := range e.Prices {
+		if e.NextBaseFee == nil {
+			fees[i] = Fee{new(big.Int).Set(price), new(big.Int).Set(price)}
+			continue
+		}
+		tip := new(big.Int).Sub(price, e.NextBaseFee)
+		if tip.Sign() < 0 {
+			tip.SetInt64(0)
+		}
+		maxFee := new(big.Int).Mul(e.NextBaseFee, big.NewInt(2))
+		fees[i] = Fee{maxFee.Add(maxFee, tip), tip}
+	}
+	return fees
+}
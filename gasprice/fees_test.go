@@ -0,0 +1,33 @@
+package gasprice
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func TestEstimateFees(t *testing.T) {
+	tests := []struct {
+		nextBaseFee *big.Int
+		prices      []int64
+		maxFees     []int64
+		tips        []int64
+	}{
+		{big.NewInt(100), []int64{90, 100, 130}, []int64{200, 200, 230}, []int64{0, 0, 30}},
+		{nil, []int64{90, 130}, []int64{90, 130}, []int64{90, 130}},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			estimate := Estimate{NextBaseFee: test.nextBaseFee}
+			for _, p := range test.prices {
+				estimate.Prices = append(estimate.Prices, big.NewInt(p))
+			}
+			for j, fee := range estimate.Fees() {
+				if fee.MaxFeePerGas.Int64() != test.maxFees[j] || fee.MaxPriorityFeePerGas.Int64() != test.tips[j] {
+					t.Errorf("fee %d is %d and %d", j, fee.MaxFeePerGas, fee.MaxPriorityFeePerGas)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package gasprice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var ErrPreEcotone = errors.New("l1 fee upper bound needs the ecotone upgrade")
+
+// gasPriceOracleAddress is the address of the GasPriceOracle predeploy on OP
+// Stack chains.
+var gasPriceOracleAddress = common.HexToAddress("0x420000000000000000000000000000000000000F")
+
+const gasPriceOracleABI = `[
+	{"name":"isEcotone","type":"function","stateMutability":"view","inputs":[],"outputs":[{"type":"bool"}]},
+	{"name":"isFjord","type":"function","stateMutability":"view","inputs":[],"outputs":[{"type":"bool"}]},
+	{"name":"getL1Fee","type":"function","stateMutability":"view","inputs":[{"type":"bytes"}],"outputs":[{"type":"uint256"}]},
+	{"name":"getL1FeeUpperBound","type":"function","stateMutability":"view","inputs":[{"type":"uint256"}],"outputs":[{"type":"uint256"}]}
+]`
+
+type Caller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// OptimismOracle reads the L1 data fees from the GasPriceOracle, at the given
+// block so every call of a request sees the same state.
+type OptimismOracle struct {
+	caller Caller
+	abi    abi.ABI
+}
+
+func NewOptimismOracle(caller Caller) (*OptimismOracle, error) {
+	parsed, err := abi.JSON(strings.NewReader(gasPriceOracleABI))
+	if err != nil {
+		return nil, err
+	}
+	return &OptimismOracle{caller, parsed}, nil
+}
+
+func (o *OptimismOracle) call(ctx context.Context, block *big.Int, method string, args ...interface{}) (interface{}, error) {
+	data, err := o.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	msg := ethereum.CallMsg{To: &gasPriceOracleAddress, Data: data}
+	output, err := o.caller.CallContract(ctx, msg, block)
+	if err != nil {
+		return nil, err
+	}
+	values, err := o.abi.Unpack(method, output)
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// reverted reports whether the node rejected a call because its execution
+// reverted.
+func reverted(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return err != nil && strings.Contains(err.Error(), "execution reverted")
+}
+
+// upgraded reads an upgrade flag of the GasPriceOracle, which is false when
+// the oracle predates the flag and the call reverts. Other failures are
+// returned as they are, so an unreachable node is not taken for an old oracle.
+func (o *OptimismOracle) upgraded(ctx context.Context, block *big.Int, flag string) (bool, error) {
+	value, err := o.call(ctx, block, flag)
+	if reverted(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return value.(bool), nil
+}
+
+// L1Fee returns the L1 data fee of the raw unsigned transaction as computed
+// by the GasPriceOracle.
+func (o *OptimismOracle) L1Fee(ctx context.Context, tx []byte, block *big.Int) (*big.Int, error) {
+	fee, err := o.call(ctx, block, "getL1Fee", tx)
+	if err != nil {
+		return nil, err
+	}
+	return fee.(*big.Int), nil
+}
+
+// L1FeeForSize returns an upper bound of the L1 data fee of a transaction
+// with the given unsigned size. Since Fjord the GasPriceOracle computes it,
+// while on Ecotone it is the fee of a transaction without any zero byte.
+// Older chains price the transactions differently and return ErrPreEcotone.
+func (o *OptimismOracle) L1FeeForSize(ctx context.Context, size uint64, block *big.Int) (*big.Int, error) {
+	fjord, err := o.upgraded(ctx, block, "isFjord")
+	if err != nil {
+		return nil, err
+	}
+	if fjord {
+		fee, err := o.call(ctx, block, "getL1FeeUpperBound", new(big.Int).SetUint64(size))
+		if err != nil {
+			return nil, err
+		}
+		return fee.(*big.Int), nil
+	}
+
+	ecotone, err := o.upgraded(ctx, block, "isEcotone")
+	if err != nil {
+		return nil, err
+	}
+	if !ecotone {
+		return nil, ErrPreEcotone
+	}
+	return o.L1Fee(ctx, bytes.Repeat([]byte{0xff}, int(size)), block)
+}
@@ -0,0 +1,156 @@
+package gasprice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// revertError is the error of a node rejecting a call.
+type revertError struct{}
+
+func (revertError) Error() string { return "execution reverted" }
+
+func (revertError) ErrorCode() int { return 3 }
+
+// callerMock answers the calls of the methods it has outputs for, reverts the
+// others and records the blocks and inputs of the calls.
+type callerMock struct {
+	abi     abi.ABI
+	outputs map[string][]interface{}
+	err     error
+	blocks  []*big.Int
+	inputs  map[string][]byte
+}
+
+func newCallerMock(abi abi.ABI, outputs map[string][]interface{}) *callerMock {
+	return &callerMock{abi, outputs, nil, nil, make(map[string][]byte)}
+}
+
+func (c *callerMock) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.blocks = append(c.blocks, blockNumber)
+	if c.err != nil {
+		return nil, c.err
+	}
+	for name, outputs := range c.outputs {
+		method := c.abi.Methods[name]
+		if bytes.HasPrefix(msg.Data, method.ID) {
+			c.inputs[name] = msg.Data[len(method.ID):]
+			return method.Outputs.Pack(outputs...)
+		}
+	}
+	return nil, revertError{}
+}
+
+func TestOptimismOracleL1FeeForSize(t *testing.T) {
+	oracle, err := NewOptimismOracle(nil)
+	if err != nil {
+		t.Fatal("could not create oracle:", err)
+	}
+	fjord := map[string][]interface{}{
+		"isEcotone":          {true},
+		"isFjord":            {true},
+		"getL1Fee":           {big.NewInt(12345)},
+		"getL1FeeUpperBound": {big.NewInt(36771840136)},
+	}
+	ecotone := map[string][]interface{}{
+		"isEcotone": {true},
+		"getL1Fee":  {big.NewInt(12345)},
+	}
+	bedrock := map[string][]interface{}{
+		"getL1Fee": {big.NewInt(12345)},
+	}
+
+	tests := []struct {
+		outputs  map[string][]interface{}
+		method   string
+		expected *big.Int
+		err      error
+	}{
+		{fjord, "getL1FeeUpperBound", big.NewInt(36771840136), nil},
+		{ecotone, "getL1Fee", big.NewInt(12345), nil},
+		{bedrock, "", nil, ErrPreEcotone},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			caller := newCallerMock(oracle.abi, test.outputs)
+			oracle.caller = caller
+			block := big.NewInt(120)
+			fee, err := oracle.L1FeeForSize(context.Background(), 100, block)
+			if err != test.err {
+				t.Fatalf("L1FeeForSize returned error %v but expected %v", err, test.err)
+			}
+			if test.err != nil {
+				return
+			}
+			if fee.Cmp(test.expected) != 0 {
+				t.Errorf("L1FeeForSize returned %d but expected %d", fee, test.expected)
+			}
+			if _, ok := caller.inputs[test.method]; !ok {
+				t.Errorf("L1FeeForSize did not call %s", test.method)
+			}
+			for _, b := range caller.blocks {
+				if b != block {
+					t.Fatalf("L1FeeForSize called the oracle at block %v", b)
+				}
+			}
+		})
+	}
+
+	// on ecotone the fee is the one of a transaction without zero bytes
+	caller := newCallerMock(oracle.abi, ecotone)
+	oracle.caller = caller
+	if _, err := oracle.L1FeeForSize(context.Background(), 100, big.NewInt(120)); err != nil {
+		t.Fatal("L1FeeForSize returned error:", err)
+	}
+	values, err := oracle.abi.Methods["getL1Fee"].Inputs.Unpack(caller.inputs["getL1Fee"])
+	if err != nil || !bytes.Equal(values[0].([]byte), bytes.Repeat([]byte{0xff}, 100)) {
+		t.Error("L1FeeForSize did not price a transaction without zero bytes")
+	}
+
+	// failing calls are not mistaken for an older oracle
+	failure := errors.New("connection refused")
+	caller = newCallerMock(oracle.abi, fjord)
+	caller.err = failure
+	oracle.caller = caller
+	if _, err := oracle.L1FeeForSize(context.Background(), 100, big.NewInt(120)); err != failure {
+		t.Errorf("L1FeeForSize returned error %v but expected %v", err, failure)
+	}
+
+	// neither are the errors the node returns for other reasons
+	limited := rpcError{-32005, "rate limit exceeded"}
+	caller.err = limited
+	if _, err := oracle.L1FeeForSize(context.Background(), 100, big.NewInt(120)); err != limited {
+		t.Errorf("L1FeeForSize returned error %v but expected %v", err, limited)
+	}
+}
+
+func TestOptimismOracleL1Fee(t *testing.T) {
+	oracle, err := NewOptimismOracle(nil)
+	if err != nil {
+		t.Fatal("could not create oracle:", err)
+	}
+	caller := newCallerMock(oracle.abi, map[string][]interface{}{
+		"getL1Fee": {big.NewInt(12345)},
+	})
+	oracle.caller = caller
+
+	block := big.NewInt(120)
+	fee, err := oracle.L1Fee(context.Background(), []byte{1, 2, 3}, block)
+	if err != nil {
+		t.Fatal("L1Fee returned error:", err)
+	}
+	if fee.Cmp(big.NewInt(12345)) != 0 {
+		t.Errorf("L1Fee returned wrong fee %d", fee)
+	}
+	if len(caller.blocks) != 1 || caller.blocks[0] != block {
+		t.Error("L1Fee did not call the oracle at the block")
+	}
+}
@@ -0,0 +1,150 @@
+package gasprice
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+const maxQuerySize = 32
+const queryCacheSize = 64
+
+// sortedSample is the merged sample an estimate is computed from, kept so
+// arbitrary bands and percentiles can be computed for the same head. The
+// prices are sorted and the gas is nil unless the estimate is weighted by gas.
+type sortedSample struct {
+	prices bigIntHeap
+	gas    []uint64
+	cache  map[string][]*big.Int
+	lock   sync.Mutex
+}
+
+func totalGas(gas []uint64) uint64 {
+	var total uint64
+	for _, g := range gas {
+		total += g
+	}
+	return total
+}
+
+func newSortedSample(prices bigIntHeap, gas []uint64) *sortedSample {
+	if gas != nil {
+		sort.Sort(weightedPrices{prices, gas})
+		if totalGas(gas) == 0 {
+			gas = nil
+		}
+	} else {
+		sort.Sort(prices)
+	}
+	return &sortedSample{
+		prices,
+		gas,
+		make(map[string][]*big.Int),
+		sync.Mutex{},
+	}
+}
+
+func (s *sortedSample) bands(targets []Target) []*big.Int {
+	if s.gas != nil {
+		return weightedEstimates(s.prices, s.gas, targets)
+	}
+	return bandEstimates(s.prices, targets)
+}
+
+// percentiles returns the nearest rank price of each percentile, ranking by
+// gas if the sample is weighted.
+func (s *sortedSample) percentiles(percentiles []float64) []*big.Int {
+	n := len(s.prices)
+	results := make([]*big.Int, len(percentiles))
+	for i, p := range percentiles {
+		if s.gas == nil {
+			rank := int(math.Ceil(p/100*float64(n))) - 1
+			if rank < 0 {
+				rank = 0
+			}
+			if rank >= n {
+				rank = n - 1
+			}
+			results[i] = new(big.Int).Set(s.prices[rank])
+			continue
+		}
+
+		threshold := p / 100 * float64(totalGas(s.gas))
+		var used uint64
+		results[i] = new(big.Int).Set(s.prices[n-1])
+		for j, price := range s.prices {
+			used += s.gas[j]
+			if used > 0 && float64(used) >= threshold {
+				results[i] = new(big.Int).Set(price)
+				break
+			}
+		}
+	}
+	return results
+}
+
+func (s *sortedSample) cached(key string, compute func() []*big.Int) []*big.Int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if prices, ok := s.cache[key]; ok {
+		return clonePrices(prices)
+	}
+	if len(s.cache) >= queryCacheSize {
+		s.cache = make(map[string][]*big.Int)
+	}
+	prices := compute()
+	s.cache[key] = prices
+	return clonePrices(prices)
+}
+
+func (e *Estimator) query(ctx context.Context, key string, compute func(s *sortedSample) []*big.Int) (Estimate, error) {
+	estimate, err := e.GasPrices(ctx)
+	if err != nil {
+		return Estimate{}, err
+	}
+	sample := estimate.sample
+	if sample == nil {
+		return Estimate{}, ErrNoSample
+	}
+
+	estimate.Prices = sample.cached(key, func() []*big.Int { return compute(sample) })
+	estimate.BlobPrices = nil
+	return estimate, nil
+}
+
+// Bands estimates the gas prices of the targets for the current head from the
+// same sample as GasPrices.
+func (e *Estimator) Bands(ctx context.Context, targets []Target) (Estimate, error) {
+	if len(targets) == 0 || len(targets) > maxQuerySize {
+		return Estimate{}, ErrBadTargets
+	}
+	if err := validateTargets(targets); err != nil {
+		return Estimate{}, err
+	}
+
+	key := fmt.Sprint("bands", targets)
+	return e.query(ctx, key, func(s *sortedSample) []*big.Int {
+		return s.bands(targets)
+	})
+}
+
+// Percentiles returns the gas prices at the percentiles, between 0 and 100,
+// for the current head from the same sample as GasPrices.
+func (e *Estimator) Percentiles(ctx context.Context, percentiles []float64) (Estimate, error) {
+	if len(percentiles) == 0 || len(percentiles) > maxQuerySize {
+		return Estimate{}, ErrBadPercentiles
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return Estimate{}, ErrBadPercentiles
+		}
+	}
+
+	key := fmt.Sprint("percentiles", percentiles)
+	return e.query(ctx, key, func(s *sortedSample) []*big.Int {
+		return s.percentiles(percentiles)
+	})
+}
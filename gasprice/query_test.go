@@ -0,0 +1,86 @@
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSortedSamplePercentiles(t *testing.T) {
+	tests := []struct {
+		prices      []int64
+		gas         []uint64
+		percentiles []float64
+		expected    []int64
+	}{
+		{[]int64{30, 10, 20, 40}, nil, []float64{0, 25, 50, 90, 100}, []int64{10, 10, 20, 40, 40}},
+		{[]int64{30, 10, 20}, []uint64{100, 100, 200}, []float64{0, 25, 50, 75, 80}, []int64{10, 10, 20, 20, 30}},
+		{[]int64{30, 10}, []uint64{0, 0}, []float64{50}, []int64{10}},
+	}
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			prices := make(bigIntHeap, len(test.prices))
+			for j, p := range test.prices {
+				prices[j] = big.NewInt(p)
+			}
+			sample := newSortedSample(prices, test.gas)
+			results := sample.percentiles(test.percentiles)
+			for j, expected := range test.expected {
+				if results[j].Cmp(big.NewInt(expected)) != 0 {
+					t.Errorf("percentile %v is %d but expected %d", test.percentiles[j], results[j], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimatorQuery(t *testing.T) {
+	samples := []Sample{{
+		&types.Header{Number: big.NewInt(0)},
+		[]*big.Int{big.NewInt(40), big.NewInt(10), big.NewInt(30), big.NewInt(20)},
+		nil,
+		nil,
+	}}
+	tracker := newTrackerMock(samples[0].header)
+	sampler := newSamplerMock(samples)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	estimator, err := NewEstimator(ctx, tracker, tracker, sampler, 0, 1, []Target{{0, 1}}, EthereumBaseFeeConfig, nil)
+	if err != nil {
+		t.Fatal("could not create estimator")
+	}
+
+	estimate, err := estimator.Percentiles(ctx, []float64{50, 100})
+	if err != nil {
+		t.Fatal("Percentiles returned error:", err)
+	}
+	if len(estimate.Prices) != 2 || estimate.Prices[0].Int64() != 20 || estimate.Prices[1].Int64() != 40 {
+		t.Fatal("Percentiles returned wrong prices")
+	}
+	estimate.Prices[0].SetInt64(0)
+	estimate, err = estimator.Percentiles(ctx, []float64{50, 100})
+	if err != nil || estimate.Prices[0].Int64() != 20 {
+		t.Fatal("Percentiles returned a shared cached price")
+	}
+
+	estimate, err = estimator.Bands(ctx, []Target{{0, 0.5}, {0.5, 1}})
+	if err != nil {
+		t.Fatal("Bands returned error:", err)
+	}
+	if len(estimate.Prices) != 2 || estimate.Prices[0].Int64() != 15 || estimate.Prices[1].Int64() != 35 {
+		t.Fatal("Bands returned wrong prices")
+	}
+
+	if _, err := estimator.Percentiles(ctx, []float64{101}); err != ErrBadPercentiles {
+		t.Error("Percentiles accepted an invalid percentile")
+	}
+	if _, err := estimator.Bands(ctx, []Target{{0.5, 0.2}}); err != ErrBadTargets {
+		t.Error("Bands accepted an invalid target")
+	}
+	if _, err := estimator.Bands(ctx, nil); err != ErrBadTargets {
+		t.Error("Bands accepted no targets")
+	}
+}
@@ -2,24 +2,50 @@ package gasprice
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"math/big"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 	lru "github.com/hashicorp/golang-lru"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const cacheSize = 128
+const prefetchConcurrency = 8
+
+var ErrMissingReceipt = errors.New("transaction receipt is missing")
 
 type Sample struct {
-	header *types.Header
-	prices []*big.Int
+	header     *types.Header
+	prices     []*big.Int
+	gas        []uint64
+	blobPrices []*big.Int
 }
 
 type Sampler interface {
 	sample(ctx context.Context, hash common.Hash) (Sample, error)
+	prefetch(ctx context.Context, head *types.Header, count int)
+}
+
+type Batcher interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+type ReceiptProvider interface {
+	BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
 }
 
 type sampleResult struct {
@@ -29,51 +55,185 @@ type sampleResult struct {
 
 type MinimumSampler struct {
 	provider Provider
+	batcher  Batcher
+	receipts ReceiptProvider
 	size     int
 	minPrice *big.Int
 	cache    *lru.Cache
 	chans    map[common.Hash][]chan<- sampleResult
 	lock     sync.Mutex
+	txSigner types.Signer
+	sigLock  sync.Mutex
+	// noBlockReceipts is set once the node turns out not to support
+	// eth_getBlockReceipts
+	noBlockReceipts atomic.Bool
 }
 
-func NewMinimumSampler(provider Provider, size int, minPrice *big.Int) (*MinimumSampler, error) {
+func NewMinimumSampler(
+	provider Provider,
+	batcher Batcher,
+	receipts ReceiptProvider,
+	size int,
+	minPrice *big.Int,
+) (*MinimumSampler, error) {
 	cache, err := lru.New(cacheSize)
 	if err != nil {
 		return nil, err
 	}
 	return &MinimumSampler{
 		provider,
+		batcher,
+		receipts,
 		size,
 		minPrice,
 		cache,
 		make(map[common.Hash][]chan<- sampleResult),
 		sync.Mutex{},
+		nil,
+		sync.Mutex{},
+		atomic.Bool{},
 	}, nil
 }
 
-func (s *MinimumSampler) fetch(ctx context.Context, hash common.Hash) (Sample, error) {
-	block, err := s.provider.BlockByHash(ctx, hash)
+func (s *MinimumSampler) signer(ctx context.Context) (types.Signer, error) {
+	s.sigLock.Lock()
+	defer s.sigLock.Unlock()
+	if s.txSigner != nil {
+		return s.txSigner, nil
+	}
+
+	chainID, err := s.provider.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.txSigner = types.LatestSignerForChainID(chainID)
+	return s.txSigner, nil
+}
+
+// methodNotFound reports whether the node rejected a call because it does
+// not support its method.
+func methodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "method not found")
+}
+
+// fetchReceipts fetches the receipts of the block at once, or one by one
+// from nodes without eth_getBlockReceipts. Other failures are returned as
+// they are, as falling back would only add load to a struggling node.
+func (s *MinimumSampler) fetchReceipts(ctx context.Context, block *types.Block) (map[common.Hash]*types.Receipt, error) {
+	var receipts []*types.Receipt
+	var err error
+	if !s.noBlockReceipts.Load() {
+		receipts, err = s.receipts.BlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if methodNotFound(err) {
+			slog.WarnContext(ctx, "fallback to fetching the receipts one by one", "err", err)
+			s.noBlockReceipts.Store(true)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if s.noBlockReceipts.Load() {
+		receipts, err = s.fetchTransactionReceipts(ctx, block.Transactions())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byHash := make(map[common.Hash]*types.Receipt, len(receipts))
+	for _, receipt := range receipts {
+		byHash[receipt.TxHash] = receipt
+	}
+	return byHash, nil
+}
+
+func (s *MinimumSampler) fetchTransactionReceipts(ctx context.Context, txs types.Transactions) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(txs))
+	errs := make([]error, len(txs))
+	sem := make(chan struct{}, prefetchConcurrency)
+	var wg sync.WaitGroup
+	for i, tx := range txs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, hash common.Hash) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			receipts[i], errs[i] = s.receipts.TransactionReceipt(ctx, hash)
+		}(i, tx.Hash())
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receipts, nil
+}
+
+func (s *MinimumSampler) process(ctx context.Context, block *types.Block) (Sample, error) {
+	signer, err := s.signer(ctx)
 	if err != nil {
 		return Sample{}, err
 	}
 
+	var receipts map[common.Hash]*types.Receipt
+	if s.receipts != nil {
+		ctx, span := tracer.Start(ctx, "MinimumSampler.fetchReceipts")
+		receipts, err = s.fetchReceipts(ctx, block)
+		endSpan(span, err)
+		if err != nil {
+			return Sample{}, err
+		}
+	}
+
+	// blocks without a base fee are from a chain without EIP-1559, so their
+	// transactions pay the gas price they set.
 	baseFee := block.BaseFee()
 	coinbase := block.Coinbase()
 	txs := block.Transactions()
+	_, span := tracer.Start(ctx, "MinimumSampler.recoverSenders", trace.WithAttributes(attribute.Int("transactions", len(txs))))
+	defer span.End()
 	pricesHeap := make(bigIntHeap, 0, len(txs))
+	gasUsed := make([]uint64, 0, len(txs))
+	blobPrices := make([]*big.Int, 0)
 	for _, tx := range txs {
-		tip, err := tx.EffectiveGasTip(baseFee)
-		if err != nil {
-			return Sample{}, err
+		price := tx.GasPrice()
+		if baseFee != nil {
+			tip, err := tx.EffectiveGasTip(baseFee)
+			if err != nil {
+				return Sample{}, err
+			}
+			price = new(big.Int).Add(baseFee, tip)
 		}
 
-		price := new(big.Int).Add(baseFee, tip)
+		var gas uint64
+		if receipts != nil {
+			receipt, ok := receipts[tx.Hash()]
+			if !ok {
+				return Sample{}, ErrMissingReceipt
+			}
+			if receipt.Status == types.ReceiptStatusFailed {
+				continue
+			}
+			if receipt.EffectiveGasPrice != nil {
+				price = receipt.EffectiveGasPrice
+			}
+			gas = receipt.GasUsed
+		}
+		if tx.Type() == types.BlobTxType {
+			blobPrices = append(blobPrices, tx.BlobGasFeeCap())
+		}
 		if price.Cmp(s.minPrice) == -1 {
 			continue
 		}
 
-		sender, err := types.NewLondonSigner(tx.ChainId()).Sender(tx)
+		sender, err := types.Sender(signer, tx)
 		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 			return Sample{}, err
 		}
 		if sender == coinbase {
@@ -81,33 +241,81 @@ func (s *MinimumSampler) fetch(ctx context.Context, hash common.Hash) (Sample, e
 		}
 
 		pricesHeap = append(pricesHeap, price)
+		gasUsed = append(gasUsed, gas)
 	}
 
 	prices := make([]*big.Int, 0, s.size)
+	var gas []uint64
+	if receipts != nil {
+		gas = make([]uint64, 0, s.size)
+	}
 	for len(prices) < s.size && pricesHeap.Len() > 0 {
 		price := pricesHeap.Pop().(*big.Int)
 		prices = append(prices, price)
+		if gas != nil {
+			gas = append(gas, gasUsed[pricesHeap.Len()])
+		}
 	}
 
-	return Sample{block.Header(), prices}, nil
+	return Sample{block.Header(), prices, gas, blobPrices}, nil
+}
+
+func (s *MinimumSampler) fetch(ctx context.Context, hash common.Hash) (Sample, error) {
+	block, err := s.provider.BlockByHash(ctx, hash)
+	if err != nil {
+		return Sample{}, err
+	}
+	return s.process(ctx, block)
 }
 
-func (s *MinimumSampler) broadcastSample(hash common.Hash) {
+// broadcastSample fetches the sample of the block for everyone waiting on it,
+// in a span linked to the one of whoever asked first.
+func (s *MinimumSampler) broadcastSample(hash common.Hash, link trace.Link) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 
+	ctx, span := tracer.Start(ctx, "MinimumSampler.fetch", trace.WithAttributes(attribute.String("block.hash", hash.Hex())), trace.WithLinks(link))
 	sample, err := s.fetch(ctx, hash)
+	endSpan(span, err)
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	if err != nil {
-		for _, ch := range s.chans[hash] {
-			ch <- sampleResult{Sample{}, err}
-			close(ch)
-		}
-		delete(s.chans, hash)
+		s.fail(hash, err)
 		return
 	}
 
+	s.store(hash, sample)
+}
+
+// fail hands the error to everyone waiting on the sample. The lock must be
+// held by the caller.
+func (s *MinimumSampler) fail(hash common.Hash, err error) {
+	for _, ch := range s.chans[hash] {
+		ch <- sampleResult{Sample{}, err}
+		close(ch)
+	}
+	delete(s.chans, hash)
+}
+
+// claim marks the sample of the block as being fetched by the caller, who
+// must then store it or fail, so requests for it wait instead of fetching it
+// again. It returns false if the sample is cached or already being fetched.
+func (s *MinimumSampler) claim(hash common.Hash) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.cache.Contains(hash) {
+		return false
+	}
+	if _, ok := s.chans[hash]; ok {
+		return false
+	}
+	s.chans[hash] = nil
+	return true
+}
+
+// store caches the sample and hands it to everyone waiting on it. The lock
+// must be held by the caller.
+func (s *MinimumSampler) store(hash common.Hash, sample Sample) {
 	s.cache.Add(hash, sample)
 	for _, ch := range s.chans[hash] {
 		ch <- sampleResult{sample, nil}
@@ -116,7 +324,7 @@ func (s *MinimumSampler) broadcastSample(hash common.Hash) {
 	delete(s.chans, hash)
 }
 
-func (s *MinimumSampler) asyncSample(hash common.Hash) <-chan sampleResult {
+func (s *MinimumSampler) asyncSample(ctx context.Context, hash common.Hash) <-chan sampleResult {
 	ch := make(chan sampleResult, 1)
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -126,10 +334,10 @@ func (s *MinimumSampler) asyncSample(hash common.Hash) <-chan sampleResult {
 		return ch
 	}
 
-	chans := append(s.chans[hash], ch)
-	s.chans[hash] = chans
-	if len(chans) == 1 {
-		go s.broadcastSample(hash)
+	chans, fetching := s.chans[hash]
+	s.chans[hash] = append(chans, ch)
+	if !fetching {
+		go s.broadcastSample(hash, trace.LinkFromContext(ctx))
 	}
 	return ch
 }
@@ -138,10 +346,209 @@ func (s *MinimumSampler) sample(ctx context.Context, hash common.Hash) (Sample,
 	if value, ok := s.cache.Get(hash); ok {
 		return value.(Sample), nil
 	}
+	ctx, span := tracer.Start(ctx, "MinimumSampler.wait", trace.WithAttributes(attribute.String("block.hash", hash.Hex())))
 	select {
-	case r := <-s.asyncSample(hash):
+	case r := <-s.asyncSample(ctx, hash):
+		endSpan(span, r.err)
 		return r.sample, r.err
 	case <-ctx.Done():
+		endSpan(span, ctx.Err())
 		return Sample{}, ctx.Err()
 	}
 }
+
+// Follow fetches the sample of every new head of the tracker as soon as the
+// tracker receives it, so it is already cached when the estimator asks for it.
+func (s *MinimumSampler) Follow(ctx context.Context, tracker Tracker) {
+	subscription := tracker.subscribe()
+	go func() {
+		for {
+			select {
+			case head := <-subscription.ch:
+				s.asyncSample(ctx, head.Hash())
+			case <-ctx.Done():
+				subscription.unsubscribe()
+				return
+			}
+		}
+	}()
+}
+
+// missing walks the cached ancestors of head and returns the hash and the
+// numbers of the uncached blocks among the count latest ones, starting with
+// the hash of the first of them.
+func (s *MinimumSampler) missing(head *types.Header, count int) (common.Hash, []*big.Int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	hash := head.ParentHash
+	number := head.Number.Uint64()
+	remaining := count - 1
+	for remaining > 0 && number > 0 {
+		value, ok := s.cache.Peek(hash)
+		if !ok {
+			break
+		}
+		hash = value.(Sample).header.ParentHash
+		number--
+		remaining--
+	}
+
+	numbers := make([]*big.Int, 0, remaining)
+	for ; remaining > 0 && number > 0; remaining-- {
+		number--
+		numbers = append(numbers, new(big.Int).SetUint64(number))
+	}
+	return hash, numbers
+}
+
+func (s *MinimumSampler) fetchBatch(ctx context.Context, numbers []*big.Int) ([]*types.Block, error) {
+	results := make([]json.RawMessage, len(numbers))
+	batch := make([]rpc.BatchElem, len(numbers))
+	for i, number := range numbers {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeBig(number), true},
+			Result: &results[i],
+		}
+	}
+	if err := s.batcher.BatchCallContext(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*types.Block, 0, len(numbers))
+	for i, elem := range batch {
+		if elem.Error != nil {
+			slog.WarnContext(ctx, "could not prefetch block", "number", numbers[i], "err", elem.Error)
+			continue
+		}
+		block, err := decodeBlock(results[i])
+		if err != nil {
+			slog.WarnContext(ctx, "could not prefetch block", "number", numbers[i], "err", err)
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func (s *MinimumSampler) fetchParallel(ctx context.Context, numbers []*big.Int) []*types.Block {
+	blocks := make([]*types.Block, len(numbers))
+	sem := make(chan struct{}, prefetchConcurrency)
+	var wg sync.WaitGroup
+	for i, number := range numbers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, number *big.Int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			block, err := s.provider.BlockByNumber(ctx, number)
+			if err != nil {
+				slog.WarnContext(ctx, "could not prefetch block", "number", number, "err", err)
+				return
+			}
+			blocks[i] = block
+		}(i, number)
+	}
+	wg.Wait()
+
+	fetched := blocks[:0]
+	for _, block := range blocks {
+		if block != nil {
+			fetched = append(fetched, block)
+		}
+	}
+	return fetched
+}
+
+// ancestors returns the blocks that follow each other by parent hash starting
+// with the one with the hash, and stops at the first missing one, so blocks
+// of another fork are left out.
+func ancestors(hash common.Hash, blocks []*types.Block) []*types.Block {
+	byHash := make(map[common.Hash]*types.Block, len(blocks))
+	for _, block := range blocks {
+		byHash[block.Hash()] = block
+	}
+	chain := make([]*types.Block, 0, len(blocks))
+	for {
+		block, ok := byHash[hash]
+		if !ok {
+			return chain
+		}
+		chain = append(chain, block)
+		hash = block.ParentHash()
+	}
+}
+
+// prefetch fetches the samples of the count latest blocks up to head at once,
+// by number, so walking the parent hashes afterwards is served from the cache.
+// Only the blocks that are ancestors of head by parent hash are kept, and each
+// of them is claimed first so it is not fetched twice. Failures are logged, as
+// the walk falls back to fetching each block by hash.
+func (s *MinimumSampler) prefetch(ctx context.Context, head *types.Header, count int) {
+	ctx, span := tracer.Start(ctx, "MinimumSampler.prefetch", headerAttributes(head))
+	defer span.End()
+	headCh := s.asyncSample(ctx, head.Hash())
+
+	hash, numbers := s.missing(head, count)
+	span.SetAttributes(attribute.Int("missing", len(numbers)))
+	var blocks []*types.Block
+	if len(numbers) > 1 && s.batcher != nil {
+		var err error
+		blocks, err = s.fetchBatch(ctx, numbers)
+		if err != nil {
+			slog.WarnContext(ctx, "could not prefetch blocks", "err", err)
+		}
+	} else if len(numbers) > 0 {
+		blocks = s.fetchParallel(ctx, numbers)
+	}
+	chain := ancestors(hash, blocks)
+	if len(chain) < len(blocks) {
+		slog.DebugContext(ctx, "dropped prefetched blocks of another fork", "count", len(blocks)-len(chain))
+	}
+
+	var wg sync.WaitGroup
+	for _, block := range chain {
+		if !s.claim(block.Hash()) {
+			continue
+		}
+		wg.Add(1)
+		go func(block *types.Block) {
+			defer wg.Done()
+			sample, err := s.process(ctx, block)
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			if err != nil {
+				slog.WarnContext(ctx, "could not prefetch sample", "number", block.Number(), "hash", block.Hash(), "err", err)
+				s.fail(block.Hash(), err)
+				return
+			}
+			s.store(block.Hash(), sample)
+		}(block)
+	}
+	wg.Wait()
+
+	select {
+	case <-headCh:
+	case <-ctx.Done():
+	}
+}
+
+func decodeBlock(raw json.RawMessage) (*types.Block, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ethereum.NotFound
+	}
+
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+	var body struct {
+		Transactions []*types.Transaction `json:"transactions"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+
+	return types.NewBlockWithHeader(&header).WithBody(types.Body{Transactions: body.Transactions}), nil
+}
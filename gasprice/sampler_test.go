@@ -0,0 +1,490 @@
+package gasprice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type providerMock struct {
+	Provider
+	chainID *big.Int
+}
+
+func (p providerMock) ChainID(ctx context.Context) (*big.Int, error) {
+	return p.chainID, nil
+}
+
+func TestMinimumSamplerMissing(t *testing.T) {
+	headers := make([]*types.Header, 6)
+	headers[0] = &types.Header{Number: big.NewInt(0)}
+	for i := 1; i < len(headers); i++ {
+		headers[i] = &types.Header{ParentHash: headers[i-1].Hash(), Number: big.NewInt(int64(i))}
+	}
+
+	tests := []struct {
+		cached  []int
+		head    int
+		count   int
+		missing []uint64
+	}{
+		{nil, 5, 3, []uint64{4, 3}},
+		{[]int{4}, 5, 3, []uint64{3}},
+		{[]int{4, 3}, 5, 3, []uint64{}},
+		{[]int{3}, 5, 3, []uint64{4, 3}},
+		{nil, 1, 5, []uint64{0}},
+		{nil, 5, 1, []uint64{}},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			sampler, err := NewMinimumSampler(nil, nil, nil, 7, big.NewInt(0))
+			if err != nil {
+				t.Fatal("could not create sampler:", err)
+			}
+			for _, c := range test.cached {
+				sampler.cache.Add(headers[c].Hash(), Sample{headers[c], nil, nil, nil})
+			}
+			hash, missing := sampler.missing(headers[test.head], test.count)
+			if len(missing) > 0 && hash != headers[missing[0].Uint64()].Hash() {
+				t.Error("missing returned the hash of another block")
+			}
+			if len(missing) != len(test.missing) {
+				t.Fatalf("missing returned %d numbers but expected %d", len(missing), len(test.missing))
+			}
+			for j := range missing {
+				if missing[j].Uint64() != test.missing[j] {
+					t.Errorf("missing returned %d but expected %d", missing[j], test.missing[j])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeBlock(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(7), BaseFee: big.NewInt(100), Difficulty: big.NewInt(0)}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(200),
+		Gas:       21000,
+	})
+
+	raw, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal("could not marshal header:", err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal("could not unmarshal header:", err)
+	}
+	fields["transactions"] = []*types.Transaction{tx}
+	raw, err = json.Marshal(fields)
+	if err != nil {
+		t.Fatal("could not marshal block:", err)
+	}
+
+	block, err := decodeBlock(raw)
+	if err != nil {
+		t.Fatal("decodeBlock returned error:", err)
+	}
+	if block.Hash() != header.Hash() {
+		t.Error("decoded block has wrong hash")
+	}
+	if len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != tx.Hash() {
+		t.Error("decoded block has wrong transactions")
+	}
+
+	if _, err := decodeBlock(json.RawMessage("null")); err == nil {
+		t.Error("decodeBlock did not fail on null block")
+	}
+}
+
+func TestMinimumSamplerProcessLegacy(t *testing.T) {
+	chainID := big.NewInt(56)
+	signer := types.LatestSignerForChainID(chainID)
+	key, _ := crypto.GenerateKey()
+	coinbaseKey, _ := crypto.GenerateKey()
+
+	txs := make([]*types.Transaction, 0)
+	for i, price := range []int64{5, 7, 9, 8} {
+		k := key
+		if price == 9 {
+			k = coinbaseKey
+		}
+		tx, err := types.SignNewTx(k, signer, &types.LegacyTx{
+			Nonce:    uint64(i),
+			GasPrice: big.NewInt(price),
+			Gas:      21000,
+		})
+		if err != nil {
+			t.Fatal("could not sign transaction:", err)
+		}
+		txs = append(txs, tx)
+	}
+	header := &types.Header{
+		Number:   big.NewInt(1),
+		Coinbase: crypto.PubkeyToAddress(coinbaseKey.PublicKey),
+	}
+	block := types.NewBlockWithHeader(header).WithBody(types.Body{Transactions: txs})
+
+	sampler, err := NewMinimumSampler(providerMock{chainID: chainID}, nil, nil, 7, big.NewInt(6))
+	if err != nil {
+		t.Fatal("could not create sampler:", err)
+	}
+	sample, err := sampler.process(context.Background(), block)
+	if err != nil {
+		t.Fatal("process returned error:", err)
+	}
+	expected := []*big.Int{big.NewInt(8), big.NewInt(7)}
+	if len(sample.prices) != len(expected) {
+		t.Fatalf("process returned %d prices but expected %d", len(sample.prices), len(expected))
+	}
+	for i := range expected {
+		if sample.prices[i].Cmp(expected[i]) != 0 {
+			t.Errorf("process returned %d but expected %d", sample.prices[i], expected[i])
+		}
+	}
+}
+
+func encodeBlock(t *testing.T, block *types.Block) json.RawMessage {
+	raw, err := json.Marshal(block.Header())
+	if err != nil {
+		t.Fatal("could not marshal header:", err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal("could not unmarshal header:", err)
+	}
+	fields["transactions"] = block.Transactions()
+	raw, err = json.Marshal(fields)
+	if err != nil {
+		t.Fatal("could not marshal block:", err)
+	}
+	return raw
+}
+
+// chainMock serves the blocks by number, as a batch or one by one, and by
+// hash, counting the requests by hash.
+type chainMock struct {
+	t        *testing.T
+	byNumber map[uint64]*types.Block
+	byHash   map[common.Hash]*types.Block
+	failing  map[uint64]bool
+	hashed   map[common.Hash]int
+	lock     sync.Mutex
+}
+
+func newChainMock(t *testing.T, blocks ...*types.Block) *chainMock {
+	c := &chainMock{
+		t,
+		make(map[uint64]*types.Block),
+		make(map[common.Hash]*types.Block),
+		make(map[uint64]bool),
+		make(map[common.Hash]int),
+		sync.Mutex{},
+	}
+	for _, block := range blocks {
+		c.byNumber[block.NumberU64()] = block
+		c.byHash[block.Hash()] = block
+	}
+	return c
+}
+
+func (c *chainMock) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (c *chainMock) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return nil, ErrBlockNotFound
+}
+
+func (c *chainMock) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.hashed[hash]++
+	if block, ok := c.byHash[hash]; ok {
+		return block, nil
+	}
+	return nil, ErrBlockNotFound
+}
+
+func (c *chainMock) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if c.failing[number.Uint64()] {
+		return nil, ErrBlockNotFound
+	}
+	if block, ok := c.byNumber[number.Uint64()]; ok {
+		return block, nil
+	}
+	return nil, ErrBlockNotFound
+}
+
+func (c *chainMock) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, rpc.ErrNotificationsUnsupported
+}
+
+func (c *chainMock) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	for i := range b {
+		number, err := hexutil.DecodeBig(b[i].Args[0].(string))
+		if err != nil {
+			return err
+		}
+		result := b[i].Result.(*json.RawMessage)
+		if c.failing[number.Uint64()] {
+			b[i].Error = ErrBlockNotFound
+			continue
+		}
+		block, ok := c.byNumber[number.Uint64()]
+		if !ok {
+			*result = json.RawMessage("null")
+			continue
+		}
+		*result = encodeBlock(c.t, block)
+	}
+	return nil
+}
+
+func (c *chainMock) requestsByHash(hash common.Hash) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.hashed[hash]
+}
+
+// newBlocks returns a chain of empty blocks numbered from 0.
+func newBlocks(n int) []*types.Block {
+	blocks := make([]*types.Block, n)
+	var parent common.Hash
+	for i := range blocks {
+		header := &types.Header{ParentHash: parent, Number: big.NewInt(int64(i)), Difficulty: big.NewInt(0)}
+		blocks[i] = types.NewBlockWithHeader(header)
+		parent = blocks[i].Hash()
+	}
+	return blocks
+}
+
+func blockNumbers(blocks []*types.Block) []uint64 {
+	numbers := make([]uint64, len(blocks))
+	for i, block := range blocks {
+		numbers[i] = block.NumberU64()
+	}
+	return numbers
+}
+
+func TestMinimumSamplerFetch(t *testing.T) {
+	blocks := newBlocks(5)
+	chain := newChainMock(t, blocks...)
+	chain.failing[2] = true
+	sampler, err := NewMinimumSampler(chain, chain, nil, 7, big.NewInt(0))
+	if err != nil {
+		t.Fatal("could not create sampler:", err)
+	}
+	numbers := []*big.Int{big.NewInt(3), big.NewInt(2), big.NewInt(1), big.NewInt(9)}
+
+	batched, err := sampler.fetchBatch(context.Background(), numbers)
+	if err != nil {
+		t.Fatal("fetchBatch returned error:", err)
+	}
+	parallel := sampler.fetchParallel(context.Background(), numbers)
+	for name, fetched := range map[string][]*types.Block{"fetchBatch": batched, "fetchParallel": parallel} {
+		got := blockNumbers(fetched)
+		if len(got) != 2 || got[0] != 3 || got[1] != 1 {
+			t.Errorf("%s returned blocks %v but expected [3 1]", name, got)
+			continue
+		}
+		if fetched[0].Hash() != blocks[3].Hash() || fetched[1].Hash() != blocks[1].Hash() {
+			t.Errorf("%s returned blocks with wrong hashes", name)
+		}
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	blocks := newBlocks(5)
+	fork := types.NewBlockWithHeader(&types.Header{ParentHash: blocks[1].Hash(), Number: big.NewInt(2), Extra: []byte("fork")})
+
+	tests := []struct {
+		hash     common.Hash
+		blocks   []*types.Block
+		expected []uint64
+	}{
+		{blocks[3].Hash(), []*types.Block{blocks[3], blocks[2], blocks[1]}, []uint64{3, 2, 1}},
+		{blocks[3].Hash(), []*types.Block{blocks[1], blocks[3], blocks[2]}, []uint64{3, 2, 1}},
+		{blocks[3].Hash(), []*types.Block{blocks[3], fork, blocks[1]}, []uint64{3}},
+		{blocks[3].Hash(), []*types.Block{fork, blocks[1]}, []uint64{}},
+	}
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			got := blockNumbers(ancestors(test.hash, test.blocks))
+			if len(got) != len(test.expected) {
+				t.Fatalf("ancestors returned %v but expected %v", got, test.expected)
+			}
+			for j := range got {
+				if got[j] != test.expected[j] {
+					t.Fatalf("ancestors returned %v but expected %v", got, test.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestMinimumSamplerPrefetch(t *testing.T) {
+	blocks := newBlocks(6)
+	// the provider serves block 3 of another fork by number, as if it
+	// reorged after the head arrived
+	fork := types.NewBlockWithHeader(&types.Header{ParentHash: blocks[2].Hash(), Number: big.NewInt(3), Extra: []byte("fork")})
+	chain := newChainMock(t, blocks...)
+	chain.byNumber[3] = fork
+	chain.byHash[fork.Hash()] = fork
+
+	for _, batcher := range []Batcher{chain, nil} {
+		sampler, err := NewMinimumSampler(chain, batcher, nil, 7, big.NewInt(0))
+		if err != nil {
+			t.Fatal("could not create sampler:", err)
+		}
+		sampler.prefetch(context.Background(), blocks[5].Header(), 5)
+
+		for _, block := range []*types.Block{blocks[5], blocks[4]} {
+			if !sampler.cache.Contains(block.Hash()) {
+				t.Errorf("block %d was not prefetched", block.NumberU64())
+			}
+		}
+		for _, block := range []*types.Block{fork, blocks[3], blocks[2]} {
+			if sampler.cache.Contains(block.Hash()) {
+				t.Errorf("block %d %x past the fork was cached", block.NumberU64(), block.Hash())
+			}
+		}
+
+		// the walk fetches the canonical block by hash
+		sample, err := sampler.sample(context.Background(), blocks[3].Hash())
+		if err != nil || sample.header.Hash() != blocks[3].Hash() {
+			t.Fatal("sample did not fetch the canonical block")
+		}
+	}
+}
+
+func TestMinimumSamplerPrefetchClaimed(t *testing.T) {
+	blocks := newBlocks(4)
+	chain := newChainMock(t, blocks...)
+	sampler, err := NewMinimumSampler(chain, chain, nil, 7, big.NewInt(0))
+	if err != nil {
+		t.Fatal("could not create sampler:", err)
+	}
+
+	// a claimed block is waited on instead of being fetched by hash
+	if !sampler.claim(blocks[2].Hash()) {
+		t.Fatal("claim failed on an uncached block")
+	}
+	if sampler.claim(blocks[2].Hash()) {
+		t.Fatal("claim succeeded twice")
+	}
+	ch := sampler.asyncSample(context.Background(), blocks[2].Hash())
+	sampler.prefetch(context.Background(), blocks[3].Header(), 3)
+	select {
+	case <-ch:
+		t.Fatal("sample of the claimed block was delivered before it was stored")
+	default:
+	}
+
+	sample := Sample{blocks[2].Header(), nil, nil, nil}
+	sampler.lock.Lock()
+	sampler.store(blocks[2].Hash(), sample)
+	sampler.lock.Unlock()
+	if r := <-ch; r.err != nil || r.sample.header.Hash() != blocks[2].Hash() {
+		t.Fatal("waiting request did not get the stored sample")
+	}
+	if n := chain.requestsByHash(blocks[2].Hash()); n != 0 {
+		t.Fatalf("claimed block was fetched by hash %d times", n)
+	}
+	if !sampler.cache.Contains(blocks[1].Hash()) {
+		t.Fatal("block 1 was not prefetched")
+	}
+}
+
+// receiptsMock answers with the receipts of its transactions, failing
+// eth_getBlockReceipts with its error, and counts the calls.
+type receiptsMock struct {
+	receipts      map[common.Hash]*types.Receipt
+	err           error
+	blockCalls    int32
+	receiptsCalls int32
+}
+
+func (r *receiptsMock) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	atomic.AddInt32(&r.blockCalls, 1)
+	if r.err != nil {
+		return nil, r.err
+	}
+	receipts := make([]*types.Receipt, 0, len(r.receipts))
+	for _, receipt := range r.receipts {
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+func (r *receiptsMock) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	atomic.AddInt32(&r.receiptsCalls, 1)
+	return r.receipts[txHash], nil
+}
+
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string { return e.message }
+
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestMinimumSamplerFetchReceipts(t *testing.T) {
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1})
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}).WithBody(types.Body{Transactions: types.Transactions{tx}})
+	receipts := map[common.Hash]*types.Receipt{tx.Hash(): {TxHash: tx.Hash(), GasUsed: 21000}}
+	timeout := errors.New("context deadline exceeded")
+
+	tests := []struct {
+		err           error
+		expected      error
+		blockCalls    int32
+		receiptsCalls int32
+	}{
+		{nil, nil, 2, 0},
+		{rpcError{-32601, "the method eth_getBlockReceipts does not exist/is not available"}, nil, 1, 2},
+		{errors.New("Method not found"), nil, 1, 2},
+		{rpcError{-32005, "rate limit exceeded"}, rpcError{-32005, "rate limit exceeded"}, 2, 0},
+		{timeout, timeout, 2, 0},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			mock := &receiptsMock{receipts, test.err, 0, 0}
+			sampler, err := NewMinimumSampler(nil, nil, mock, 7, big.NewInt(0))
+			if err != nil {
+				t.Fatal("could not create sampler:", err)
+			}
+			for j := 0; j < 2; j++ {
+				byHash, err := sampler.fetchReceipts(context.Background(), block)
+				if err != test.expected {
+					t.Fatalf("fetchReceipts returned error %v but expected %v", err, test.expected)
+				}
+				if err == nil && byHash[tx.Hash()].GasUsed != 21000 {
+					t.Fatal("fetchReceipts returned wrong receipts")
+				}
+			}
+			if mock.blockCalls != test.blockCalls || mock.receiptsCalls != test.receiptsCalls {
+				t.Errorf("fetchReceipts made %d block and %d transaction calls but expected %d and %d",
+					mock.blockCalls, mock.receiptsCalls, test.blockCalls, test.receiptsCalls)
+			}
+		})
+	}
+}
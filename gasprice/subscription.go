@@ -0,0 +1,44 @@
+package gasprice
+
+import "sync"
+
+// estimateFeed fans out new estimates to subscribers. Like the trackers, a
+// slow subscriber only misses intermediate estimates and always receives the
+// latest one.
+type estimateFeed struct {
+	subs map[chan Estimate]struct{}
+	lock sync.Mutex
+}
+
+func newEstimateFeed() estimateFeed {
+	return estimateFeed{make(map[chan Estimate]struct{}), sync.Mutex{}}
+}
+
+// Subscribe returns a channel receiving every new estimate and a function to
+// stop receiving them.
+func (f *estimateFeed) Subscribe() (<-chan Estimate, func()) {
+	ch := make(chan Estimate, 1)
+	f.lock.Lock()
+	f.subs[ch] = struct{}{}
+	f.lock.Unlock()
+
+	unsubscribe := func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		delete(f.subs, ch)
+	}
+	return ch, unsubscribe
+}
+
+func (f *estimateFeed) publish(estimate *Estimate) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for ch := range f.subs {
+		// only publish sends on the channel, so it has room once drained
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- estimate.clone()
+	}
+}
@@ -0,0 +1,30 @@
+package gasprice
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEstimateFeed(t *testing.T) {
+	feed := newEstimateFeed()
+	ch, unsubscribe := feed.Subscribe()
+
+	feed.publish(&Estimate{Block: Block{Number: 1}, Prices: []*big.Int{big.NewInt(1)}})
+	last := &Estimate{Block: Block{Number: 2}, Prices: []*big.Int{big.NewInt(2)}}
+	feed.publish(last)
+	estimate := <-ch
+	if estimate.Block.Number != 2 {
+		t.Fatal("subscriber did not receive the latest estimate")
+	}
+	if estimate.Prices[0] == last.Prices[0] {
+		t.Fatal("subscriber received a shared estimate")
+	}
+
+	unsubscribe()
+	feed.publish(last)
+	select {
+	case <-ch:
+		t.Fatal("unsubscribed channel received an estimate")
+	default:
+	}
+}
@@ -0,0 +1,37 @@
+package gasprice
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/ArmanMazdaee/yaegpe/gasprice")
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func headerAttributes(header *types.Header) trace.SpanStartEventOption {
+	return trace.WithAttributes(
+		attribute.Int64("block.number", header.Number.Int64()),
+		attribute.String("block.hash", header.Hash().Hex()),
+	)
+}
+
+// trackedHead gets the head of the tracker within a span, where role tells which
+// of the trackers of the estimator it is.
+func trackedHead(ctx context.Context, tracker Tracker, role string) (*types.Header, error) {
+	ctx, span := tracer.Start(ctx, "Tracker.head", trace.WithAttributes(attribute.String("tracker", role)))
+	header, err := tracker.head(ctx)
+	endSpan(span, err)
+	return header, err
+}
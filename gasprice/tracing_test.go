@@ -0,0 +1,51 @@
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEstimatorGasPricesSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	samples := []Sample{{&types.Header{Number: big.NewInt(0)}, []*big.Int{big.NewInt(30)}, nil, nil}}
+	tracker := newTrackerMock(samples[0].header)
+	estimator := &Estimator{
+		tracker:  tracker,
+		anchor:   tracker,
+		sampler:  newSamplerMock(samples),
+		history:  1,
+		targets:  []Target{{0, 1}},
+		lastHead: zeroHash,
+		lock:     sync.RWMutex{},
+	}
+	if _, err := estimator.GasPrices(context.Background()); err != nil {
+		t.Fatal("GasPrices returned error:", err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	for _, name := range []string{"Estimator.GasPrices", "Tracker.head", "Estimator.wait", "Estimator.estimate"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("%s span is missing", name)
+		}
+	}
+	root := spans["Estimator.GasPrices"].SpanContext().SpanID()
+	if spans["Tracker.head"].Parent().SpanID() != root || spans["Estimator.wait"].Parent().SpanID() != root {
+		t.Fatal("spans should be children of the GasPrices span")
+	}
+	links := spans["Estimator.estimate"].Links()
+	if len(links) != 1 || links[0].SpanContext.SpanID() != spans["Estimator.wait"].SpanContext().SpanID() {
+		t.Fatal("estimate span should be linked to the wait span")
+	}
+}
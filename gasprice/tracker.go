@@ -2,49 +2,114 @@ package gasprice
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"math/big"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 var zeroHash = common.Hash{}
 var pollWait = 5 * time.Second
 
 type trackerSubscription struct {
-	ch          <-chan struct{}
+	ch          <-chan *types.Header
 	unsubscribe func()
 }
 
+// notify hands the header to the subscriber, replacing the previous header if
+// the subscriber has not received it yet.
+func notify(ch chan *types.Header, header *types.Header) {
+	for {
+		select {
+		case ch <- header:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
 type Tracker interface {
-	head(ctx context.Context) (common.Hash, error)
+	head(ctx context.Context) (*types.Header, error)
 	subscribe() trackerSubscription
+	arrival(hash common.Hash) time.Time
+}
+
+const arrivalsSize = 64
+
+// arrivals remembers when the recent headers of a tracker arrived, so the
+// latency of estimates can be measured from there rather than from when they
+// were handed to the estimator.
+type arrivals struct {
+	hashes [arrivalsSize]common.Hash
+	times  [arrivalsSize]time.Time
+	next   int
+	lock   sync.Mutex
+}
+
+func newArrivals() *arrivals {
+	return &arrivals{}
+}
+
+// markArrival records that the header arrived now, unless it arrived before.
+func (a *arrivals) markArrival(header *types.Header) {
+	hash := header.Hash()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, h := range a.hashes {
+		if h == hash {
+			return
+		}
+	}
+	a.hashes[a.next] = hash
+	a.times[a.next] = time.Now()
+	a.next = (a.next + 1) % arrivalsSize
+}
+
+// arrival returns when the header arrived, or the zero time if it is not one
+// of the recent headers.
+func (a *arrivals) arrival(hash common.Hash) time.Time {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for i, h := range a.hashes {
+		if h == hash {
+			return a.times[i]
+		}
+	}
+	return time.Time{}
 }
 
 type headResult struct {
-	header common.Hash
+	header *types.Header
 	err    error
 }
 
 type PollingTracker struct {
 	provider  Provider
 	chans     []chan<- headResult
-	subs      map[chan<- struct{}]struct{}
-	lastHead  common.Hash
+	subs      map[chan *types.Header]struct{}
+	lastHead  *types.Header
 	lastFetch time.Time
 	lock      sync.RWMutex
+	*arrivals
 }
 
 func NewPollingTracker(ctx context.Context, provider Provider) *PollingTracker {
 	t := &PollingTracker{
 		provider,
 		nil,
-		make(map[chan<- struct{}]struct{}),
-		zeroHash,
+		make(map[chan *types.Header]struct{}),
+		nil,
 		time.Time{},
 		sync.RWMutex{},
+		newArrivals(),
 	}
 	go t.poll(ctx)
 	return t
@@ -59,7 +124,7 @@ func (t *PollingTracker) broadcastHead() {
 	defer t.lock.Unlock()
 	if err != nil {
 		for _, ch := range t.chans {
-			ch <- headResult{zeroHash, err}
+			ch <- headResult{nil, err}
 			close(ch)
 		}
 		t.chans = nil
@@ -67,19 +132,16 @@ func (t *PollingTracker) broadcastHead() {
 	}
 
 	t.lastFetch = time.Now()
-	head := header.Hash()
+	t.markArrival(header)
 	for _, ch := range t.chans {
-		ch <- headResult{head, nil}
+		ch <- headResult{header, nil}
 		close(ch)
 	}
 	t.chans = nil
-	if t.lastHead != head {
-		t.lastHead = head
+	if t.lastHead == nil || t.lastHead.Hash() != header.Hash() {
+		t.lastHead = header
 		for sub := range t.subs {
-			select {
-			case sub <- struct{}{}:
-			default:
-			}
+			notify(sub, header)
 		}
 	}
 }
@@ -96,12 +158,12 @@ func (t *PollingTracker) asyncHead() <-chan headResult {
 	return ch
 }
 
-func (t *PollingTracker) head(ctx context.Context) (common.Hash, error) {
+func (t *PollingTracker) head(ctx context.Context) (*types.Header, error) {
 	select {
 	case r := <-t.asyncHead():
 		return r.header, r.err
 	case <-ctx.Done():
-		return zeroHash, ctx.Err()
+		return nil, ctx.Err()
 	}
 }
 
@@ -136,7 +198,7 @@ func (t *PollingTracker) poll(ctx context.Context) {
 func (t *PollingTracker) subscribe() trackerSubscription {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	ch := make(chan struct{}, 1)
+	ch := make(chan *types.Header, 1)
 	t.subs[ch] = struct{}{}
 
 	unsubscribe := func() {
@@ -151,23 +213,26 @@ func (t *PollingTracker) subscribe() trackerSubscription {
 
 type SubscribedTracker struct {
 	provider Provider
-	subs     map[chan<- struct{}]struct{}
-	lastHead common.Hash
+	subs     map[chan *types.Header]struct{}
+	lastHead *types.Header
 	lock     sync.RWMutex
+	*arrivals
 }
 
 func NewSubscribedTracker(ctx context.Context, provider Provider) (*SubscribedTracker, error) {
 	t := &SubscribedTracker{
 		provider,
-		make(map[chan<- struct{}]struct{}),
-		zeroHash,
+		make(map[chan *types.Header]struct{}),
+		nil,
 		sync.RWMutex{},
+		newArrivals(),
 	}
 	header, err := provider.HeaderByNumber(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	t.lastHead = header.Hash()
+	t.lastHead = header
+	t.markArrival(header)
 
 	if err := t.listen(ctx); err != nil {
 		return nil, err
@@ -187,22 +252,20 @@ func (t *SubscribedTracker) listen(ctx context.Context) error {
 		for {
 			select {
 			case header := <-ch:
+				t.markArrival(header)
 				t.lock.Lock()
-				t.lastHead = header.Hash()
+				t.lastHead = header
 				t.lock.Unlock()
 				t.lock.RLock()
 				for sub := range t.subs {
-					select {
-					case sub <- struct{}{}:
-					default:
-					}
+					notify(sub, header)
 				}
 				t.lock.RUnlock()
 			case <-ctx.Done():
 				return
 			case err := <-sub.Err():
 				for err != nil {
-					log.Println("subscriber returned err:", err)
+					slog.Warn("head subscription failed, subscribing again", "err", err)
 					err = t.listen(ctx)
 				}
 				return
@@ -213,7 +276,7 @@ func (t *SubscribedTracker) listen(ctx context.Context) error {
 	return nil
 }
 
-func (t *SubscribedTracker) head(ctx context.Context) (common.Hash, error) {
+func (t *SubscribedTracker) head(ctx context.Context) (*types.Header, error) {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 	return t.lastHead, nil
@@ -222,7 +285,102 @@ func (t *SubscribedTracker) head(ctx context.Context) (common.Hash, error) {
 func (t *SubscribedTracker) subscribe() trackerSubscription {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	ch := make(chan struct{}, 1)
+	ch := make(chan *types.Header, 1)
+	t.subs[ch] = struct{}{}
+
+	unsubscribe := func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		delete(t.subs, ch)
+		close(ch)
+	}
+
+	return trackerSubscription{ch, unsubscribe}
+}
+
+type TaggedTracker struct {
+	provider Provider
+	tag      rpc.BlockNumber
+	subs     map[chan *types.Header]struct{}
+	lastHead *types.Header
+	lock     sync.RWMutex
+	*arrivals
+}
+
+func NewTaggedTracker(
+	ctx context.Context,
+	latest Tracker,
+	provider Provider,
+	tag rpc.BlockNumber,
+) (*TaggedTracker, error) {
+	t := &TaggedTracker{
+		provider,
+		tag,
+		make(map[chan *types.Header]struct{}),
+		nil,
+		sync.RWMutex{},
+		newArrivals(),
+	}
+	header, err := provider.HeaderByNumber(ctx, big.NewInt(tag.Int64()))
+	if err != nil {
+		return nil, err
+	}
+	t.lastHead = header
+	t.markArrival(header)
+
+	go t.listen(ctx, latest.subscribe())
+
+	return t, nil
+}
+
+func (t *TaggedTracker) update() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	header, err := t.provider.HeaderByNumber(ctx, big.NewInt(t.tag.Int64()))
+	if err != nil {
+		slog.Warn("could not get tagged header", "tag", t.tag.String(), "err", err)
+		return
+	}
+
+	// safe and finalized blocks only move forward, so an older header comes
+	// from a lagging node behind the provider
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if header.Number.Cmp(t.lastHead.Number) < 0 {
+		return
+	}
+	if t.lastHead.Hash() != header.Hash() {
+		t.lastHead = header
+		t.markArrival(header)
+		for sub := range t.subs {
+			notify(sub, header)
+		}
+	}
+}
+
+func (t *TaggedTracker) listen(ctx context.Context, subscription trackerSubscription) {
+	for {
+		select {
+		case <-subscription.ch:
+			t.update()
+		case <-ctx.Done():
+			subscription.unsubscribe()
+			return
+		}
+	}
+}
+
+func (t *TaggedTracker) head(ctx context.Context) (*types.Header, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.lastHead, nil
+}
+
+func (t *TaggedTracker) subscribe() trackerSubscription {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	ch := make(chan *types.Header, 1)
 	t.subs[ch] = struct{}{}
 
 	unsubscribe := func() {
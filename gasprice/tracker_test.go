@@ -0,0 +1,245 @@
+package gasprice
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestNotify(t *testing.T) {
+	ch := make(chan *types.Header, 1)
+	first := &types.Header{Number: big.NewInt(1)}
+	second := &types.Header{Number: big.NewInt(2)}
+
+	notify(ch, first)
+	notify(ch, second)
+
+	select {
+	case header := <-ch:
+		if header != second {
+			t.Error("notify did not replace the pending header")
+		}
+	default:
+		t.Fatal("notify did not send the header")
+	}
+	select {
+	case <-ch:
+		t.Error("notify sent more than one header")
+	default:
+	}
+}
+
+func TestArrivals(t *testing.T) {
+	a := newArrivals()
+	headers := make([]*types.Header, arrivalsSize+1)
+	for i := range headers {
+		headers[i] = &types.Header{Number: big.NewInt(int64(i))}
+		a.markArrival(headers[i])
+	}
+
+	if !a.arrival(headers[0].Hash()).IsZero() {
+		t.Error("arrival remembered the oldest header")
+	}
+	last := a.arrival(headers[arrivalsSize].Hash())
+	if last.IsZero() {
+		t.Fatal("arrival forgot the newest header")
+	}
+	a.markArrival(headers[arrivalsSize])
+	if a.arrival(headers[arrivalsSize].Hash()) != last {
+		t.Error("markArrival replaced the arrival of a known header")
+	}
+	if a.arrival(headers[1].Hash()).IsZero() {
+		t.Error("markArrival evicted a header for a known one")
+	}
+}
+
+type headerResult struct {
+	header *types.Header
+	err    error
+}
+
+// taggedProviderMock answers the header requests with the queued results and
+// records the requested numbers.
+type taggedProviderMock struct {
+	Provider
+	results chan headerResult
+	numbers chan *big.Int
+}
+
+func newTaggedProviderMock() *taggedProviderMock {
+	return &taggedProviderMock{nil, make(chan headerResult, 8), make(chan *big.Int, 8)}
+}
+
+func (p *taggedProviderMock) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	p.numbers <- number
+	r := <-p.results
+	return r.header, r.err
+}
+
+func receiveHeader(t *testing.T, ch <-chan *types.Header) *types.Header {
+	select {
+	case header := <-ch:
+		return header
+	case <-time.After(time.Second):
+		t.Fatal("tracker did not notify the subscriber")
+		return nil
+	}
+}
+
+func expectNoHeader(t *testing.T, ch <-chan *types.Header) {
+	select {
+	case header := <-ch:
+		t.Fatalf("tracker notified header %d", header.Number)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTaggedTrackerUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	provider := newTaggedProviderMock()
+	latest := newTrackerMock(&types.Header{Number: big.NewInt(100)})
+	safe := []*types.Header{
+		{Number: big.NewInt(90)},
+		{Number: big.NewInt(92)},
+	}
+
+	provider.results <- headerResult{safe[0], nil}
+	tracker, err := NewTaggedTracker(ctx, latest, provider, rpc.SafeBlockNumber)
+	if err != nil {
+		t.Fatal("NewTaggedTracker returned error:", err)
+	}
+	if number := <-provider.numbers; number.Int64() != rpc.SafeBlockNumber.Int64() {
+		t.Fatalf("tracker requested block %d instead of the safe tag", number)
+	}
+	subscription := tracker.subscribe()
+	defer subscription.unsubscribe()
+
+	// a new latest head polls the tag again
+	provider.results <- headerResult{safe[1], nil}
+	latest.changeHead(&types.Header{Number: big.NewInt(101)})
+	if header := receiveHeader(t, subscription.ch); header != safe[1] {
+		t.Fatalf("tracker notified header %d instead of 92", header.Number)
+	}
+	if number := <-provider.numbers; number.Int64() != rpc.SafeBlockNumber.Int64() {
+		t.Fatalf("tracker requested block %d instead of the safe tag", number)
+	}
+	head, err := tracker.head(ctx)
+	if err != nil || head != safe[1] {
+		t.Fatal("head is not the latest safe header")
+	}
+
+	// the same safe block is not notified again
+	provider.results <- headerResult{&types.Header{Number: big.NewInt(92)}, nil}
+	latest.changeHead(&types.Header{Number: big.NewInt(102)})
+	expectNoHeader(t, subscription.ch)
+}
+
+func TestTaggedTrackerErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	provider := newTaggedProviderMock()
+	latest := newTrackerMock(&types.Header{Number: big.NewInt(100)})
+
+	provider.results <- headerResult{nil, ErrBlockNotFound}
+	if _, err := NewTaggedTracker(ctx, latest, provider, rpc.FinalizedBlockNumber); err != ErrBlockNotFound {
+		t.Fatalf("NewTaggedTracker should return the provider error but returned %v", err)
+	}
+
+	finalized := &types.Header{Number: big.NewInt(64)}
+	provider.results <- headerResult{finalized, nil}
+	tracker, err := NewTaggedTracker(ctx, latest, provider, rpc.FinalizedBlockNumber)
+	if err != nil {
+		t.Fatal("NewTaggedTracker returned error:", err)
+	}
+	subscription := tracker.subscribe()
+	defer subscription.unsubscribe()
+
+	// a failed poll keeps the previous head
+	provider.results <- headerResult{nil, ErrBlockNotFound}
+	latest.changeHead(&types.Header{Number: big.NewInt(101)})
+	expectNoHeader(t, subscription.ch)
+	if head, _ := tracker.head(ctx); head != finalized {
+		t.Fatal("head changed after a failed poll")
+	}
+
+	// and the next poll recovers
+	next := &types.Header{Number: big.NewInt(96)}
+	provider.results <- headerResult{next, nil}
+	latest.changeHead(&types.Header{Number: big.NewInt(102)})
+	if header := receiveHeader(t, subscription.ch); header != next {
+		t.Fatalf("tracker notified header %d instead of 96", header.Number)
+	}
+}
+
+func TestTaggedTrackerOrdering(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	provider := newTaggedProviderMock()
+	latest := newTrackerMock(&types.Header{Number: big.NewInt(100)})
+
+	provider.results <- headerResult{&types.Header{Number: big.NewInt(90)}, nil}
+	tracker, err := NewTaggedTracker(ctx, latest, provider, rpc.SafeBlockNumber)
+	if err != nil {
+		t.Fatal("NewTaggedTracker returned error:", err)
+	}
+	subscription := tracker.subscribe()
+	defer subscription.unsubscribe()
+
+	headers := []*types.Header{
+		{Number: big.NewInt(92)},
+		{Number: big.NewInt(91)},
+		{Number: big.NewInt(94)},
+	}
+	expected := []*types.Header{headers[0], nil, headers[2]}
+	for i, header := range headers {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			provider.results <- headerResult{header, nil}
+			latest.changeHead(&types.Header{Number: big.NewInt(int64(101 + i))})
+			if expected[i] == nil {
+				expectNoHeader(t, subscription.ch)
+				return
+			}
+			if received := receiveHeader(t, subscription.ch); received != expected[i] {
+				t.Fatalf("tracker notified header %d instead of %d", received.Number, expected[i].Number)
+			}
+		})
+	}
+	if head, _ := tracker.head(ctx); head != headers[2] {
+		t.Fatalf("head is %d instead of 94", head.Number)
+	}
+}
+
+// TestHeaderHashPrague decodes a header of a Prague block, with the
+// requestsHash field of EIP-7685, as a node returns it and checks that it
+// hashes to the hash of the block, which the trackers and the sampler rely on.
+func TestHeaderHashPrague(t *testing.T) {
+	data, err := os.ReadFile("testdata/prague_header.json")
+	if err != nil {
+		t.Fatal("could not read header:", err)
+	}
+	var header types.Header
+	if err := json.Unmarshal(data, &header); err != nil {
+		t.Fatal("could not decode header:", err)
+	}
+	var expected struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &expected); err != nil {
+		t.Fatal("could not decode hash:", err)
+	}
+	if header.RequestsHash == nil {
+		t.Fatal("requestsHash was not decoded")
+	}
+	if header.Hash() != expected.Hash {
+		t.Fatalf("header hashes to %s but the block hash is %s", header.Hash(), expected.Hash)
+	}
+}
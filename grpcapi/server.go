@@ -0,0 +1,172 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+
+	"github.com/ArmanMazdaee/yaegpe/api/yaegpev1"
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxProjectionBlocks = 64
+
+type Estimator interface {
+	GasPrices(ctx context.Context) (gasprice.Estimate, error)
+}
+
+// Subscriber is implemented by estimators that can stream new estimates.
+type Subscriber interface {
+	Subscribe() (<-chan gasprice.Estimate, func())
+}
+
+type Server struct {
+	yaegpev1.UnimplementedGasPriceServiceServer
+	estimator Estimator
+	names     []string
+}
+
+func New(estimator Estimator, names []string) *Server {
+	return &Server{yaegpev1.UnimplementedGasPriceServiceServer{}, estimator, names}
+}
+
+func amount(x *big.Int) string {
+	if x == nil {
+		return ""
+	}
+	return x.String()
+}
+
+func newBlock(block gasprice.Block) *yaegpev1.Block {
+	return &yaegpev1.Block{Number: block.Number, Hash: block.Hash.Hex(), Timestamp: block.Time}
+}
+
+func (s *Server) tiers(prices []*big.Int) []*yaegpev1.Tier {
+	n := len(s.names)
+	if n > len(prices) {
+		n = len(prices)
+	}
+	tiers := make([]*yaegpev1.Tier, n)
+	for i := 0; i < n; i++ {
+		tiers[i] = &yaegpev1.Tier{Name: s.names[i], Price: prices[i].String()}
+	}
+	return tiers
+}
+
+func (s *Server) gasPrices(estimate gasprice.Estimate) *yaegpev1.GasPrices {
+	return &yaegpev1.GasPrices{
+		Block:       newBlock(estimate.Block),
+		Latest:      newBlock(estimate.Latest),
+		Prices:      s.tiers(estimate.Prices),
+		NextBaseFee: amount(estimate.NextBaseFee),
+		BlobBaseFee: amount(estimate.BlobBaseFee),
+		BlobPrices:  s.tiers(estimate.BlobPrices),
+	}
+}
+
+func (s *Server) estimate(ctx context.Context) (gasprice.Estimate, error) {
+	estimate, err := s.estimator.GasPrices(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not get gas price", "err", err)
+		return gasprice.Estimate{}, status.Error(codes.Unavailable, "could not get gas price")
+	}
+	return estimate, nil
+}
+
+func (s *Server) GetGasPrices(ctx context.Context, req *yaegpev1.GetGasPricesRequest) (*yaegpev1.GasPrices, error) {
+	estimate, err := s.estimate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.gasPrices(estimate), nil
+}
+
+func (s *Server) GetFees(ctx context.Context, req *yaegpev1.GetFeesRequest) (*yaegpev1.GetFeesResponse, error) {
+	estimate, err := s.estimate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &yaegpev1.GetFeesResponse{
+		Block:       newBlock(estimate.Block),
+		NextBaseFee: amount(estimate.NextBaseFee),
+	}
+	for i, fee := range estimate.Fees() {
+		if i == len(s.names) {
+			break
+		}
+		resp.Fees = append(resp.Fees, &yaegpev1.Fee{
+			Name:                 s.names[i],
+			MaxFeePerGas:         fee.MaxFeePerGas.String(),
+			MaxPriorityFeePerGas: fee.MaxPriorityFeePerGas.String(),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) GetBaseFeeProjection(ctx context.Context, req *yaegpev1.GetBaseFeeProjectionRequest) (*yaegpev1.GetBaseFeeProjectionResponse, error) {
+	if req.Blocks == 0 || req.Blocks > maxProjectionBlocks {
+		return nil, status.Error(codes.InvalidArgument, "blocks is invalid")
+	}
+	estimate, err := s.estimate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if estimate.NextBaseFee == nil {
+		return nil, status.Error(codes.FailedPrecondition, "chain has no base fee")
+	}
+
+	resp := &yaegpev1.GetBaseFeeProjectionResponse{
+		Block:  newBlock(estimate.Block),
+		Bounds: []*yaegpev1.BaseFeeBound{{Number: estimate.Block.Number + 1, Min: estimate.NextBaseFee.String(), Max: estimate.NextBaseFee.String()}},
+	}
+	if estimate.BaseFeeParams == nil && req.Blocks > 1 {
+		return nil, status.Error(codes.FailedPrecondition, "chain has no base fee projection")
+	}
+	if req.Blocks == 1 {
+		return resp, nil
+	}
+	lows, highs := estimate.BaseFeeParams.BaseFeeBounds(estimate.NextBaseFee, int(req.Blocks)-1)
+	for i := range lows {
+		resp.Bounds = append(resp.Bounds, &yaegpev1.BaseFeeBound{
+			Number: estimate.Block.Number + uint64(i) + 2,
+			Min:    lows[i].String(),
+			Max:    highs[i].String(),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) WatchGasPrices(req *yaegpev1.WatchGasPricesRequest, stream yaegpev1.GasPriceService_WatchGasPricesServer) error {
+	subscriber, ok := s.estimator.(Subscriber)
+	if !ok {
+		return status.Error(codes.Unimplemented, "estimator can not stream estimates")
+	}
+	ch, unsubscribe := subscriber.Subscribe()
+	defer unsubscribe()
+
+	estimate, err := s.estimate(stream.Context())
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(s.gasPrices(estimate)); err != nil {
+		return err
+	}
+	last := estimate.Block.Hash
+	for {
+		select {
+		case estimate := <-ch:
+			if estimate.Block.Hash == last {
+				continue
+			}
+			last = estimate.Block.Hash
+			if err := stream.Send(s.gasPrices(estimate)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
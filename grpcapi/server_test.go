@@ -0,0 +1,119 @@
+package grpcapi
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/ArmanMazdaee/yaegpe/api/yaegpev1"
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var blockMock = gasprice.Block{Number: 42, Hash: common.HexToHash("0x2a"), Time: 1646000000}
+
+type estimatorMock struct {
+	ch chan gasprice.Estimate
+}
+
+func (e estimatorMock) GasPrices(ctx context.Context) (gasprice.Estimate, error) {
+	return gasprice.Estimate{
+		Block:         blockMock,
+		Latest:        blockMock,
+		Prices:        []*big.Int{big.NewInt(90), big.NewInt(130)},
+		NextBaseFee:   big.NewInt(100),
+		BaseFeeParams: &gasprice.BaseFeeParams{Denominator: 8, Elasticity: 2},
+	}, nil
+}
+
+func (e estimatorMock) Subscribe() (<-chan gasprice.Estimate, func()) {
+	return e.ch, func() {}
+}
+
+func newClient(t *testing.T, estimator Estimator) yaegpev1.GasPriceServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	yaegpev1.RegisterGasPriceServiceServer(server, New(estimator, []string{"low", "high"}))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal("could not connect:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return yaegpev1.NewGasPriceServiceClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	client := newClient(t, estimatorMock{})
+	ctx := context.Background()
+
+	prices, err := client.GetGasPrices(ctx, &yaegpev1.GetGasPricesRequest{})
+	if err != nil {
+		t.Fatal("GetGasPrices returned error:", err)
+	}
+	if len(prices.Prices) != 2 || prices.Prices[1].Name != "high" || prices.Prices[1].Price != "130" || prices.NextBaseFee != "100" {
+		t.Errorf("GetGasPrices returned wrong prices: %v", prices)
+	}
+	if prices.Block.Number != 42 || prices.BlobBaseFee != "" {
+		t.Errorf("GetGasPrices returned wrong block: %v", prices)
+	}
+
+	fees, err := client.GetFees(ctx, &yaegpev1.GetFeesRequest{})
+	if err != nil {
+		t.Fatal("GetFees returned error:", err)
+	}
+	if len(fees.Fees) != 2 || fees.Fees[0].MaxPriorityFeePerGas != "0" || fees.Fees[1].MaxFeePerGas != "230" {
+		t.Errorf("GetFees returned wrong fees: %v", fees)
+	}
+
+	projection, err := client.GetBaseFeeProjection(ctx, &yaegpev1.GetBaseFeeProjectionRequest{Blocks: 2})
+	if err != nil {
+		t.Fatal("GetBaseFeeProjection returned error:", err)
+	}
+	bounds := projection.Bounds
+	if len(bounds) != 2 || bounds[0].Number != 43 || bounds[0].Min != "100" || bounds[1].Min != "88" || bounds[1].Max != "112" {
+		t.Errorf("GetBaseFeeProjection returned wrong bounds: %v", bounds)
+	}
+	_, err = client.GetBaseFeeProjection(ctx, &yaegpev1.GetBaseFeeProjectionRequest{Blocks: 0})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("GetBaseFeeProjection accepted no blocks")
+	}
+}
+
+func TestServerWatchGasPrices(t *testing.T) {
+	ch := make(chan gasprice.Estimate, 2)
+	client := newClient(t, estimatorMock{ch})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchGasPrices(ctx, &yaegpev1.WatchGasPricesRequest{})
+	if err != nil {
+		t.Fatal("WatchGasPrices returned error:", err)
+	}
+	first, err := stream.Recv()
+	if err != nil || first.Block.Number != 42 {
+		t.Fatal("WatchGasPrices did not send the current estimate")
+	}
+
+	ch <- gasprice.Estimate{Block: blockMock}
+	next := gasprice.Block{Number: 43, Hash: common.HexToHash("0x2b")}
+	ch <- gasprice.Estimate{Block: next, Prices: []*big.Int{big.NewInt(1)}}
+	second, err := stream.Recv()
+	if err != nil || second.Block.Number != 43 || second.Prices[0].Price != "1" {
+		t.Fatal("WatchGasPrices did not send the new estimate")
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+)
+
+// etag identifies the response by the blocks it was estimated from and the
+// query, which selects the tiers, units and formats of the body. Fiat prices
+// change independently of the blocks, so they are hashed in when present.
+func etag(estimate gasprice.Estimate, query string, fiat map[string]fiatResponse) string {
+	tag := estimate.Block.Hash.Hex()[2:18] + estimate.Latest.Hash.Hex()[2:18]
+	currencies := make([]string, 0, len(fiat))
+	for currency := range fiat {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s;", query)
+	for _, currency := range currencies {
+		fmt.Fprintf(h, "%s=%s;", currency, fiat[currency].Price)
+	}
+	return fmt.Sprintf(`"%s%016x"`, tag, h.Sum64())
+}
+
+// setCacheHeaders lets caches keep the response until the block after the
+// latest one is expected.
+func setCacheHeaders(w http.ResponseWriter, tag string, latest gasprice.Block, blockTime time.Duration, now time.Time) {
+	modified := time.Unix(int64(latest.Time), 0)
+	maxAge := modified.Add(blockTime).Sub(now) / time.Second
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(maxAge), 10))
+}
+
+// notModified reports whether the If-None-Match header of the request
+// matches the tag. The comparison is weak as RFC 9110 requires, so the tag
+// still matches when a proxy marked it weak.
+func notModified(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
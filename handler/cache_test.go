@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetCacheHeaders(t *testing.T) {
+	tests := []struct {
+		now      int64
+		expected string
+	}{
+		{1646000030, "public, max-age=6"},
+		{1646000050, "public, max-age=0"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		setCacheHeaders(w, `"tag"`, latestMock, 12*time.Second, time.Unix(test.now, 0))
+		if w.Header().Get("Cache-Control") != test.expected {
+			t.Errorf("Cache-Control is %s but expected %s", w.Header().Get("Cache-Control"), test.expected)
+		}
+		if w.Header().Get("Last-Modified") != "Sun, 27 Feb 2022 22:13:44 GMT" {
+			t.Errorf("Last-Modified is %s", w.Header().Get("Last-Modified"))
+		}
+	}
+}
+
+func TestHandlerServeHttpNotModified(t *testing.T) {
+	handler := New(estimatorMock{big.NewInt(32)}, []string{"low"}, nil, 12*time.Second)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || tag == "" {
+		t.Fatal("response has no ETag")
+	}
+
+	for _, header := range []string{tag, `"other", ` + tag, "W/" + tag, "*"} {
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("If-None-Match", header)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s should get an empty %d but got %d", header, http.StatusNotModified, w.Code)
+		}
+		if w.Header().Get("ETag") != tag {
+			t.Error("not modified response has no ETag")
+		}
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", `"other"`)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("status code should be %d but it is %d", http.StatusOK, w.Code)
+	}
+
+	// the body of another query is another representation
+	r = httptest.NewRequest(http.MethodGet, "/?unit=wei", nil)
+	r.Header.Set("If-None-Match", tag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == tag {
+		t.Errorf("query did not change the ETag %s", tag)
+	}
+}
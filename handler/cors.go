@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var exposedHeaders = []string{"ETag", "Last-Modified", "Cache-Control", "Retry-After", "X-Request-ID"}
+
+// CORS lets browsers of the allowed origins call the wrapped handler and
+// answers their preflight requests. It also marks every response as JSON
+// unless the wrapped handler says otherwise.
+type CORS struct {
+	next    http.Handler
+	origins []string
+	methods []string
+	headers []string
+	maxAge  time.Duration
+}
+
+// NewCORS wraps next, where an origin of "*" allows every origin.
+func NewCORS(next http.Handler, origins []string, methods []string, headers []string, maxAge time.Duration) *CORS {
+	return &CORS{next, origins, methods, headers, maxAge}
+}
+
+func (c *CORS) allowOrigin(origin string) string {
+	if slices.Contains(c.origins, "*") {
+		return "*"
+	}
+	if slices.Contains(c.origins, origin) {
+		return origin
+	}
+	return ""
+}
+
+func (c *CORS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// the response depends on the origin unless every origin is allowed, and
+	// caches must not hand the one of a request without it to an allowed one
+	if !slices.Contains(c.origins, "*") {
+		w.Header().Add("Vary", "Origin")
+	}
+	origin := r.Header.Get("Origin")
+	allowed := ""
+	if origin != "" {
+		allowed = c.allowOrigin(origin)
+	}
+	if allowed != "" {
+		w.Header().Set("Access-Control-Allow-Origin", allowed)
+	}
+
+	if r.Method != http.MethodOptions {
+		if allowed != "" {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+		}
+		c.next.ServeHTTP(w, r)
+		return
+	}
+
+	// preflight requests are answered here so they need no api key
+	w.Header().Set("Allow", strings.Join(c.methods, ", "))
+	if allowed != "" && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.headers, ", "))
+		w.Header().Set("Access-Control-Max-Age", strconv.FormatInt(int64(c.maxAge.Seconds()), 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	handler := NewCORS(
+		New(estimatorMock{big.NewInt(32)}, []string{"low"}, nil, 12*time.Second),
+		[]string{"https://app.example"},
+		[]string{"GET", "OPTIONS"},
+		[]string{"X-API-Key"},
+		10*time.Minute,
+	)
+	tests := []struct {
+		method    string
+		origin    string
+		preflight bool
+		code      int
+		allowed   string
+		maxAge    string
+	}{
+		{http.MethodGet, "", false, http.StatusOK, "", ""},
+		{http.MethodGet, "https://app.example", false, http.StatusOK, "https://app.example", ""},
+		{http.MethodGet, "https://other.example", false, http.StatusOK, "", ""},
+		{http.MethodOptions, "https://app.example", true, http.StatusNoContent, "https://app.example", "600"},
+		{http.MethodOptions, "https://other.example", true, http.StatusNoContent, "", ""},
+		{http.MethodOptions, "", false, http.StatusNoContent, "", ""},
+	}
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "/", nil)
+			if test.origin != "" {
+				r.Header.Set("Origin", test.origin)
+			}
+			if test.preflight {
+				r.Header.Set("Access-Control-Request-Method", "GET")
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != test.code {
+				t.Errorf("status code should be %d but it is %d", test.code, w.Code)
+			}
+			if w.Header().Get("Access-Control-Allow-Origin") != test.allowed {
+				t.Errorf("allowed origin should be %s but it is %s", test.allowed, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+			if w.Header().Get("Access-Control-Max-Age") != test.maxAge {
+				t.Errorf("max age should be %s but it is %s", test.maxAge, w.Header().Get("Access-Control-Max-Age"))
+			}
+			if w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("content type is %s", w.Header().Get("Content-Type"))
+			}
+			// even responses without the origin or to other origins vary by it
+			if w.Header().Get("Vary") != "Origin" {
+				t.Errorf("vary should be Origin but it is %s", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	handler := NewCORS(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusBadRequest, "bad request")
+		}),
+		[]string{"*"},
+		nil,
+		nil,
+		0,
+	)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://any.example")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("allowed origin is %s", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w.Header().Get("Vary") != "" {
+		t.Errorf("vary should be empty but it is %s", w.Header().Get("Vary"))
+	}
+	if w.Body.String() != "{\"error\":\"bad request\"}\n" {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var ErrBadFormat = errors.New("unit or format is invalid")
+
+var unitDecimals = map[string]int{
+	"wei":   0,
+	"gwei":  9,
+	"ether": 18,
+}
+
+// amount is a formatted amount which is encoded as a JSON string or, if
+// number is set, as a bare JSON number.
+type amount struct {
+	value  string
+	number bool
+}
+
+func (a amount) MarshalJSON() ([]byte, error) {
+	if a.number {
+		return []byte(a.value), nil
+	}
+	return json.Marshal(a.value)
+}
+
+func (a *amount) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		a.number = false
+		return json.Unmarshal(data, &a.value)
+	}
+	a.number = true
+	a.value = string(data)
+	return nil
+}
+
+// amountFormat formats amounts of wei as requested by the unit and format
+// query parameters. Decimal and number formats are exact, while hex, which
+// can not represent fractions, rounds up so a formatted fee is never short.
+type amountFormat struct {
+	decimals int
+	hex      bool
+	number   bool
+}
+
+var defaultFormat = amountFormat{0, false, false}
+
+func parseFormat(query url.Values) (amountFormat, error) {
+	f := defaultFormat
+	if unit := query.Get("unit"); unit != "" {
+		decimals, ok := unitDecimals[unit]
+		if !ok {
+			return amountFormat{}, ErrBadFormat
+		}
+		f.decimals = decimals
+	}
+
+	switch query.Get("format") {
+	case "", "decimal":
+	case "hex":
+		f.hex = true
+	case "number":
+		f.number = true
+	default:
+		return amountFormat{}, ErrBadFormat
+	}
+	return f, nil
+}
+
+// withUnit returns the format with its unit replaced, for fields whose unit
+// is fixed by their name.
+func (f amountFormat) withUnit(unit string) amountFormat {
+	f.decimals = unitDecimals[unit]
+	return f
+}
+
+func (f amountFormat) format(wei *big.Int) amount {
+	if f.hex {
+		return amount{hexutil.EncodeBig(ceilUnits(wei, f.decimals)), false}
+	}
+	return amount{formatUnits(wei, f.decimals), f.number}
+}
+
+func (f amountFormat) tiers(names []string, prices []*big.Int) map[string]amount {
+	results := make(map[string]amount)
+
+	n := len(names)
+	if n > len(prices) {
+		n = len(prices)
+	}
+
+	for i := 0; i < n; i++ {
+		results[names[i]] = f.format(prices[i])
+	}
+	return results
+}
+
+// formatUnits formats the non-negative amount of wei in the unit with the
+// given decimals without losing precision.
+func formatUnits(wei *big.Int, decimals int) string {
+	if decimals == 0 {
+		return wei.String()
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	quo, rem := new(big.Int).QuoRem(wei, unit, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo.String()
+	}
+	frac := rem.String()
+	frac = strings.Repeat("0", decimals-len(frac)) + frac
+	return quo.String() + "." + strings.TrimRight(frac, "0")
+}
+
+// ceilUnits converts the non-negative amount of wei to the unit with the
+// given decimals, rounding up.
+func ceilUnits(wei *big.Int, decimals int) *big.Int {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	quo, rem := new(big.Int).QuoRem(wei, unit, new(big.Int))
+	if rem.Sign() != 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	return quo
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		wei      *big.Int
+		decimals int
+		expect   string
+	}{
+		{big.NewInt(0), 9, "0"},
+		{big.NewInt(1_000_000_000), 9, "1"},
+		{big.NewInt(1_500_000_000), 9, "1.5"},
+		{big.NewInt(1), 9, "0.000000001"},
+		{big.NewInt(441_000_000_021), 9, "441.000000021"},
+		{big.NewInt(441_000_000_021), 0, "441000000021"},
+		{big.NewInt(1e18), 18, "1"},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			if result := formatUnits(test.wei, test.decimals); result != test.expect {
+				t.Errorf("formatUnits returned %s but expected %s", result, test.expect)
+			}
+		})
+	}
+}
+
+func TestAmountFormat(t *testing.T) {
+	wei := big.NewInt(1_500_000_000)
+	tests := []struct {
+		query  string
+		expect string
+		bad    bool
+	}{
+		{"", `"1500000000"`, false},
+		{"unit=gwei", `"1.5"`, false},
+		{"unit=gwei&format=number", `1.5`, false},
+		{"unit=gwei&format=hex", `"0x2"`, false},
+		{"unit=wei&format=hex", `"0x59682f00"`, false},
+		{"unit=ether&format=decimal", `"0.0000000015"`, false},
+		{"unit=szabo", ``, true},
+		{"format=octal", ``, true},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			query, _ := url.ParseQuery(test.query)
+			f, err := parseFormat(query)
+			if test.bad {
+				if err == nil {
+					t.Error("parseFormat accepted an invalid query")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("parseFormat returned error:", err)
+			}
+			data, err := json.Marshal(f.format(wei))
+			if err != nil {
+				t.Fatal("could not marshal amount:", err)
+			}
+			if string(data) != test.expect {
+				t.Errorf("amount is encoded as %s but expected %s", data, test.expect)
+			}
+			var decoded amount
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded != f.format(wei) {
+				t.Error("amount did not survive a round trip")
+			}
+		})
+	}
+}
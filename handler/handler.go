@@ -3,44 +3,196 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"math/big"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Estimator interface {
-	GasPrices(ctx context.Context) ([]*big.Int, error)
+	GasPrices(ctx context.Context) (gasprice.Estimate, error)
+}
+
+// PriceFeed reports the price of one ether in a fiat currency.
+type PriceFeed interface {
+	Price(ctx context.Context) (*big.Rat, error)
 }
 
+const transferGas = 21000
+const fiatDecimals = 4
+
+var weiPerEther = new(big.Rat).SetInt(big.NewInt(1e18))
+
+var tracer = otel.Tracer("github.com/ArmanMazdaee/yaegpe/handler")
+
 type Handler struct {
 	estimator Estimator
 	names     []string
+	feeds     map[string]PriceFeed
+	blockTime time.Duration
+}
+
+// New creates the handler of the estimates. The block time is how long
+// caches may keep an estimate after the timestamp of the latest block.
+func New(estimator Estimator, names []string, feeds map[string]PriceFeed, blockTime time.Duration) *Handler {
+	return &Handler{estimator, names, feeds, blockTime}
+}
+
+type blockResponse struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
+	Time   uint64 `json:"timestamp"`
+}
+
+type response struct {
+	Block       blockResponse           `json:"block"`
+	Latest      blockResponse           `json:"latest"`
+	Prices      map[string]amount       `json:"prices"`
+	NextBaseFee *amount                 `json:"nextBaseFee,omitempty"`
+	BlobBaseFee *amount                 `json:"blobBaseFee,omitempty"`
+	BlobPrices  map[string]amount       `json:"blobPrices,omitempty"`
+	L1          *l1Response             `json:"l1,omitempty"`
+	Fiat        map[string]fiatResponse `json:"fiat,omitempty"`
+	LatencyMs   *int64                  `json:"latencyMs,omitempty"`
+}
+
+type fiatResponse struct {
+	Price    string            `json:"price"`
+	Transfer map[string]string `json:"transfer"`
+	Gas      map[string]string `json:"gas,omitempty"`
 }
 
-func New(estimator Estimator, names []string) *Handler {
-	return &Handler{estimator, names}
+type l1Response struct {
+	L1BaseFee amount `json:"l1BaseFee"`
+	Gas       uint64 `json:"gas"`
+	Cost      amount `json:"cost"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errorResponse{message})
+}
+
+func newBlockResponse(block gasprice.Block) blockResponse {
+	return blockResponse{block.Number, block.Hash.Hex(), block.Time}
+}
+
+func fiatCosts(price *big.Rat, names []string, estimate gasprice.Estimate, gas uint64) map[string]string {
+	results := make(map[string]string)
+	for i, cost := range costs(estimate, gas, len(names)) {
+		amount := new(big.Rat).SetInt(cost)
+		amount.Mul(amount, price)
+		amount.Quo(amount, weiPerEther)
+		results[names[i]] = amount.FloatString(fiatDecimals)
+	}
+	return results
+}
+
+func (h *Handler) fiat(ctx context.Context, names []string, estimate gasprice.Estimate, gas uint64) map[string]fiatResponse {
+	if len(h.feeds) == 0 {
+		return nil
+	}
+
+	results := make(map[string]fiatResponse)
+	for currency, feed := range h.feeds {
+		ctx, span := tracer.Start(ctx, "PriceFeed.Price", trace.WithAttributes(attribute.String("currency", currency)))
+		price, err := feed.Price(ctx)
+		span.End()
+		if err != nil {
+			slog.WarnContext(ctx, "could not get fiat price", "currency", currency, "err", err)
+			continue
+		}
+		result := fiatResponse{
+			Price:    price.FloatString(fiatDecimals),
+			Transfer: fiatCosts(price, names, estimate, transferGas),
+		}
+		if gas != 0 {
+			result.Gas = fiatCosts(price, names, estimate, gas)
+		}
+		results[currency] = result
+	}
+	return results
+}
+
+func (h *Handler) newResponse(
+	ctx context.Context,
+	f amountFormat,
+	names []string,
+	estimate gasprice.Estimate,
+	gas uint64,
+) response {
+	resp := response{
+		Block:  newBlockResponse(estimate.Block),
+		Latest: newBlockResponse(estimate.Latest),
+		Prices: f.tiers(names, estimate.Prices),
+	}
+	if estimate.NextBaseFee != nil {
+		nextBaseFee := f.format(estimate.NextBaseFee)
+		resp.NextBaseFee = &nextBaseFee
+	}
+	if estimate.BlobBaseFee != nil {
+		blobBaseFee := f.format(estimate.BlobBaseFee)
+		resp.BlobBaseFee = &blobBaseFee
+		resp.BlobPrices = f.tiers(names, estimate.BlobPrices)
+	}
+	if estimate.L1 != nil {
+		resp.L1 = &l1Response{f.format(estimate.L1.L1BaseFee), estimate.L1.Gas, f.format(estimate.L1.Cost)}
+	}
+	resp.Fiat = h.fiat(ctx, names, estimate, gas)
+	if estimate.Latency != 0 {
+		latency := estimate.Latency.Milliseconds()
+		resp.LatencyMs = &latency
+	}
+	return resp
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	prices, err := h.estimator.GasPrices(r.Context())
+	f, err := parseFormat(r.URL.Query())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("internal server error"))
-		log.Println("could not get gas price:", err)
+		writeError(w, http.StatusBadRequest, "bad request")
 		return
 	}
 
-	results := make(map[string]string)
+	var gas uint64
+	if raw := r.URL.Query().Get("gas"); raw != "" {
+		var err error
+		gas, err = strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad request")
+			return
+		}
+	}
 
-	n := len(h.names)
-	if n > len(prices) {
-		n = len(prices)
+	estimate, names, err := h.estimate(r.Context(), r.URL.Query())
+	if errors.Is(err, ErrBadQuery) {
+		writeError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not get gas price", "err", err)
+		return
 	}
 
-	for i := 0; i < n; i++ {
-		results[h.names[i]] = prices[i].String()
+	resp := h.newResponse(r.Context(), f, names, estimate, gas)
+	tag := etag(estimate, r.URL.RawQuery, resp.Fiat)
+	setCacheHeaders(w, tag, estimate.Latest, h.blockTime, time.Now())
+	if notModified(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
-	if err := json.NewEncoder(w).Encode(results); err != nil {
-		log.Println("could not encode prices:", err)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.WarnContext(r.Context(), "could not encode prices", "err", err)
 	}
 }
@@ -10,12 +10,27 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/common"
 )
 
+var blockMock = gasprice.Block{Number: 42, Hash: common.HexToHash("0x2a"), Time: 1646000000}
+var latestMock = gasprice.Block{Number: 44, Hash: common.HexToHash("0x2c"), Time: 1646000024}
+
+func amounts(values map[string]string) map[string]amount {
+	results := make(map[string]amount)
+	for name, value := range values {
+		results[name] = amount{value, false}
+	}
+	return results
+}
+
 type estimatorMock []*big.Int
 
-func (e estimatorMock) GasPrices(ctx context.Context) ([]*big.Int, error) {
-	return e, nil
+func (e estimatorMock) GasPrices(ctx context.Context) (gasprice.Estimate, error) {
+	return gasprice.Estimate{Block: blockMock, Latest: latestMock, Prices: e}, nil
 }
 
 func TestHandlerServeHttp(t *testing.T) {
@@ -43,31 +58,37 @@ func TestHandlerServeHttp(t *testing.T) {
 
 	for i, test := range tests {
 		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
-			handler := Handler{test.estimator, test.names}
+			handler := Handler{test.estimator, test.names, nil, 0}
 			r := httptest.NewRequest(http.MethodGet, "/", nil)
 			w := httptest.NewRecorder()
 			handler.ServeHTTP(w, r)
 			if w.Code != http.StatusOK {
 				t.Errorf("status code should be %d but it is %d", http.StatusOK, w.Code)
 			}
-			result := make(map[string]string)
+			var result response
 			json.NewDecoder(w.Body).Decode(&result)
-			if !reflect.DeepEqual(test.expect, result) {
+			if !reflect.DeepEqual(amounts(test.expect), result.Prices) {
 				t.Error("response body is not correct")
 			}
+			if result.Block != newBlockResponse(blockMock) {
+				t.Error("response block is not correct")
+			}
+			if result.Latest != newBlockResponse(latestMock) {
+				t.Error("response latest block is not correct")
+			}
 		})
 	}
 }
 
 type faultyEstimatorMock struct{}
 
-func (e faultyEstimatorMock) GasPrices(ctx context.Context) ([]*big.Int, error) {
-	return nil, errors.New("some error")
+func (e faultyEstimatorMock) GasPrices(ctx context.Context) (gasprice.Estimate, error) {
+	return gasprice.Estimate{}, errors.New("some error")
 }
 
 func TestHandlerServeHttpError(t *testing.T) {
 	estimator := faultyEstimatorMock{}
-	handler := Handler{estimator, []string{"low", "high"}}
+	handler := Handler{estimator, []string{"low", "high"}, nil, 0}
 	r := httptest.NewRequest(http.MethodGet, "/", nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, r)
@@ -75,3 +96,99 @@ func TestHandlerServeHttpError(t *testing.T) {
 		t.Errorf("status code should be %d but it is %d", http.StatusInternalServerError, w.Code)
 	}
 }
+
+type extrasEstimatorMock struct{}
+
+func (e extrasEstimatorMock) GasPrices(ctx context.Context) (gasprice.Estimate, error) {
+	return gasprice.Estimate{
+		Block:       blockMock,
+		Latest:      latestMock,
+		Prices:      []*big.Int{big.NewInt(32), big.NewInt(64)},
+		BlobBaseFee: big.NewInt(1),
+		BlobPrices:  []*big.Int{big.NewInt(2), big.NewInt(8)},
+		L1:          &gasprice.L1Component{L1BaseFee: big.NewInt(16), Gas: 1500, Cost: big.NewInt(48000)},
+		Latency:     250 * time.Millisecond,
+	}, nil
+}
+
+func TestHandlerServeHttpExtras(t *testing.T) {
+	handler := Handler{extrasEstimatorMock{}, []string{"low", "high"}, nil, 0}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("status code should be %d but it is %d", http.StatusOK, w.Code)
+	}
+	var result response
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.BlobBaseFee == nil || *result.BlobBaseFee != (amount{"1", false}) {
+		t.Error("response blob base fee is not correct")
+	}
+	if !reflect.DeepEqual(amounts(map[string]string{"low": "2", "high": "8"}), result.BlobPrices) {
+		t.Error("response blob prices are not correct")
+	}
+	if result.L1 == nil || *result.L1 != (l1Response{amount{"16", false}, 1500, amount{"48000", false}}) {
+		t.Error("response l1 component is not correct")
+	}
+	if result.LatencyMs == nil || *result.LatencyMs != 250 {
+		t.Error("response latency is not correct")
+	}
+}
+
+func TestHandlerNewResponseNames(t *testing.T) {
+	handler := Handler{extrasEstimatorMock{}, []string{"low", "medium", "high"}, nil, 0}
+	estimate, _ := handler.estimator.GasPrices(context.Background())
+	names := []string{"p10", "p90"}
+	resp := handler.newResponse(context.Background(), defaultFormat, names, estimate, 0)
+	if !reflect.DeepEqual(amounts(map[string]string{"p10": "32", "p90": "64"}), resp.Prices) {
+		t.Error("response prices are not named after the requested tiers")
+	}
+	if !reflect.DeepEqual(amounts(map[string]string{"p10": "2", "p90": "8"}), resp.BlobPrices) {
+		t.Error("response blob prices are not named after the requested tiers")
+	}
+}
+
+type priceFeedMock struct {
+	price *big.Rat
+}
+
+func (p priceFeedMock) Price(ctx context.Context) (*big.Rat, error) {
+	if p.price == nil {
+		return nil, errors.New("some error")
+	}
+	return p.price, nil
+}
+
+func TestHandlerServeHttpFiat(t *testing.T) {
+	estimator := baseFeeEstimatorMock{big.NewInt(10_000_000_000), big.NewInt(30_000_000_000)}
+	feeds := map[string]PriceFeed{
+		"USD": priceFeedMock{big.NewRat(3000, 1)},
+		"EUR": priceFeedMock{nil},
+	}
+	handler := New(estimator, []string{"low", "high"}, feeds, 0)
+	r := httptest.NewRequest(http.MethodGet, "/?gas=100000", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code should be %d but it is %d", http.StatusOK, w.Code)
+	}
+	var result response
+	json.NewDecoder(w.Body).Decode(&result)
+	expect := map[string]fiatResponse{
+		"USD": {
+			Price:    "3000.0000",
+			Transfer: map[string]string{"low": "1.2600", "high": "1.8900"},
+			Gas:      map[string]string{"low": "6.0000", "high": "9.0000"},
+		},
+	}
+	if !reflect.DeepEqual(expect, result.Fiat) {
+		t.Errorf("response fiat is not correct: %v", result.Fiat)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?gas=abc", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status code should be %d but it is %d", http.StatusBadRequest, w.Code)
+	}
+}
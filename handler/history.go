@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/history"
+)
+
+var ErrBadRange = errors.New("from, to or resolution is invalid")
+
+const defaultHistoryRange = 24 * time.Hour
+const defaultResolution = 5 * time.Minute
+const maxHistoryPoints = 10000
+
+type HistoryStore interface {
+	Aggregate(ctx context.Context, from uint64, to uint64, resolution uint64) ([]history.Point, error)
+}
+
+type HistoryHandler struct {
+	store HistoryStore
+	names []string
+}
+
+func NewHistory(store HistoryStore, names []string) *HistoryHandler {
+	return &HistoryHandler{store, names}
+}
+
+type pointResponse struct {
+	Time       uint64            `json:"timestamp"`
+	Blocks     int               `json:"blocks"`
+	BaseFee    *amount           `json:"baseFee,omitempty"`
+	MinBaseFee *amount           `json:"minBaseFee,omitempty"`
+	MaxBaseFee *amount           `json:"maxBaseFee,omitempty"`
+	Prices     map[string]amount `json:"prices"`
+}
+
+type historyResponse struct {
+	From       uint64          `json:"from"`
+	To         uint64          `json:"to"`
+	Resolution uint64          `json:"resolution"`
+	Points     []pointResponse `json:"points"`
+}
+
+// parseRange parses the from and to unix timestamps and the resolution
+// duration, defaulting to the last day in intervals of five minutes.
+func parseRange(query url.Values, now time.Time) (uint64, uint64, uint64, error) {
+	to := uint64(now.Unix())
+	if raw := query.Get("to"); raw != "" {
+		var err error
+		if to, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return 0, 0, 0, ErrBadRange
+		}
+	}
+	from := to - uint64(defaultHistoryRange.Seconds())
+	if raw := query.Get("from"); raw != "" {
+		var err error
+		if from, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return 0, 0, 0, ErrBadRange
+		}
+	}
+	resolution := defaultResolution
+	if raw := query.Get("resolution"); raw != "" {
+		var err error
+		if resolution, err = time.ParseDuration(raw); err != nil {
+			return 0, 0, 0, ErrBadRange
+		}
+	}
+
+	seconds := uint64(resolution / time.Second)
+	if from > to || seconds == 0 || (to-from)/seconds >= maxHistoryPoints {
+		return 0, 0, 0, ErrBadRange
+	}
+	return from, to, seconds, nil
+}
+
+func optionalAmount(f amountFormat, price *big.Int) *amount {
+	if price == nil {
+		return nil
+	}
+	a := f.format(price)
+	return &a
+}
+
+func (h *HistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	f, err := parseFormat(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+	from, to, resolution, err := parseRange(query, time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	points, err := h.store.Aggregate(r.Context(), from, to, resolution)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not get history", "err", err)
+		return
+	}
+
+	if query.Get("export") == "csv" {
+		h.writeCSV(r.Context(), w, f, points)
+		return
+	}
+
+	resp := historyResponse{from, to, resolution, make([]pointResponse, len(points))}
+	for i, point := range points {
+		resp.Points[i] = pointResponse{
+			point.Time,
+			point.Blocks,
+			optionalAmount(f, point.BaseFee),
+			optionalAmount(f, point.MinBaseFee),
+			optionalAmount(f, point.MaxBaseFee),
+			f.tiers(h.names, point.Prices),
+		}
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.WarnContext(r.Context(), "could not encode history", "err", err)
+	}
+}
+
+func (h *HistoryHandler) writeCSV(ctx context.Context, w http.ResponseWriter, f amountFormat, points []history.Point) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="history.csv"`)
+
+	writer := csv.NewWriter(w)
+	header := append([]string{"timestamp", "blocks", "baseFee", "minBaseFee", "maxBaseFee"}, h.names...)
+	writer.Write(header)
+	for _, point := range points {
+		row := []string{
+			strconv.FormatUint(point.Time, 10),
+			strconv.Itoa(point.Blocks),
+			csvAmount(f, point.BaseFee),
+			csvAmount(f, point.MinBaseFee),
+			csvAmount(f, point.MaxBaseFee),
+		}
+		for i := range h.names {
+			var price *big.Int
+			if i < len(point.Prices) {
+				price = point.Prices[i]
+			}
+			row = append(row, csvAmount(f, price))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		slog.WarnContext(ctx, "could not write history", "err", err)
+	}
+}
+
+func csvAmount(f amountFormat, price *big.Int) string {
+	if price == nil {
+		return ""
+	}
+	return f.format(price).value
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/history"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseRange(t *testing.T) {
+	now := time.Unix(100000, 0)
+	tests := []struct {
+		query      string
+		from       uint64
+		to         uint64
+		resolution uint64
+		err        error
+	}{
+		{"", 100000 - 86400, 100000, 300, nil},
+		{"from=100&to=200&resolution=10s", 100, 200, 10, nil},
+		{"from=200&to=100", 0, 0, 0, ErrBadRange},
+		{"from=100&to=200&resolution=10ms", 0, 0, 0, ErrBadRange},
+		{"from=0&to=100000&resolution=1s", 0, 0, 0, ErrBadRange},
+		{"from=a", 0, 0, 0, ErrBadRange},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			query, _ := url.ParseQuery(test.query)
+			from, to, resolution, err := parseRange(query, now)
+			if err != test.err {
+				t.Fatalf("parseRange returned error %v but expected %v", err, test.err)
+			}
+			if from != test.from || to != test.to || resolution != test.resolution {
+				t.Errorf("parseRange returned %d, %d, %d", from, to, resolution)
+			}
+		})
+	}
+}
+
+func TestHistoryServeHttp(t *testing.T) {
+	store := history.NewMemory(100)
+	store.Save(context.Background(), history.Record{Hash: common.HexToHash("0x1"), Time: 100, BaseFee: big.NewInt(10), Prices: []*big.Int{big.NewInt(20), big.NewInt(40)}})
+	store.Save(context.Background(), history.Record{Number: 1, Hash: common.HexToHash("0x2"), Time: 110, BaseFee: big.NewInt(30), Prices: []*big.Int{big.NewInt(40), big.NewInt(60)}})
+	handler := NewHistory(store, []string{"low", "high"})
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/history?from=100&to=200&resolution=1m", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code should be %d but it is %d", http.StatusOK, w.Code)
+	}
+	var result historyResponse
+	json.NewDecoder(w.Body).Decode(&result)
+	if len(result.Points) != 1 || result.Points[0].Blocks != 2 || result.Points[0].BaseFee.value != "20" {
+		t.Fatalf("response points are not correct: %v", result.Points)
+	}
+	if !reflect.DeepEqual(amounts(map[string]string{"low": "30", "high": "50"}), result.Points[0].Prices) {
+		t.Errorf("response prices are not correct: %v", result.Points[0].Prices)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/v1/history?from=100&to=200&resolution=1m&export=csv", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	expected := "timestamp,blocks,baseFee,minBaseFee,maxBaseFee,low,high\n100,2,20,10,30,30,50\n"
+	if w.Body.String() != expected {
+		t.Errorf("csv export is not correct: %q", w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Error("csv export has wrong content type")
+	}
+}
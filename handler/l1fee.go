@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// maxTxSize is the largest transaction the nodes accept into their pools.
+const maxTxSize = 128 * 1024
+
+type L1FeeOracle interface {
+	L1Fee(ctx context.Context, tx []byte, block *big.Int) (*big.Int, error)
+	L1FeeForSize(ctx context.Context, size uint64, block *big.Int) (*big.Int, error)
+}
+
+type L1FeeHandler struct {
+	estimator Estimator
+	oracle    L1FeeOracle
+	names     []string
+}
+
+func NewL1Fee(estimator Estimator, oracle L1FeeOracle, names []string) *L1FeeHandler {
+	return &L1FeeHandler{estimator, oracle, names}
+}
+
+type l1FeeResponse struct {
+	Block  blockResponse     `json:"block"`
+	Prices map[string]amount `json:"prices"`
+	L1Fee  amount            `json:"l1Fee"`
+}
+
+func (h *L1FeeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	f, err := parseFormat(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	var tx []byte
+	var size uint64
+	if raw := query.Get("tx"); raw != "" {
+		tx, err = hexutil.Decode(raw)
+		if err != nil || len(tx) > maxTxSize {
+			writeError(w, http.StatusBadRequest, "bad request")
+			return
+		}
+	} else {
+		size, err = strconv.ParseUint(query.Get("size"), 10, 64)
+		if err != nil || size > maxTxSize {
+			writeError(w, http.StatusBadRequest, "bad request")
+			return
+		}
+	}
+
+	estimate, err := h.estimator.GasPrices(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not get gas price", "err", err)
+		return
+	}
+
+	// the fee is read at the estimated block so it matches the prices
+	block := new(big.Int).SetUint64(estimate.Block.Number)
+	var fee *big.Int
+	if tx != nil {
+		fee, err = h.oracle.L1Fee(r.Context(), tx, block)
+	} else {
+		fee, err = h.oracle.L1FeeForSize(r.Context(), size, block)
+	}
+	if errors.Is(err, gasprice.ErrPreEcotone) {
+		writeError(w, http.StatusNotImplemented, "size is not supported before ecotone, use tx")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not get l1 fee", "err", err)
+		return
+	}
+
+	resp := l1FeeResponse{
+		newBlockResponse(estimate.Block),
+		f.tiers(h.names, estimate.Prices),
+		f.format(fee),
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.WarnContext(r.Context(), "could not encode l1 fee", "err", err)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+)
+
+type l1FeeOracleMock struct{}
+
+func (o l1FeeOracleMock) L1Fee(ctx context.Context, tx []byte, block *big.Int) (*big.Int, error) {
+	if block.Uint64() != blockMock.Number {
+		return nil, errors.New("wrong block")
+	}
+	return big.NewInt(int64(len(tx))), nil
+}
+
+func (o l1FeeOracleMock) L1FeeForSize(ctx context.Context, size uint64, block *big.Int) (*big.Int, error) {
+	if block.Uint64() != blockMock.Number {
+		return nil, errors.New("wrong block")
+	}
+	if size == 7 {
+		return nil, gasprice.ErrPreEcotone
+	}
+	return new(big.Int).SetUint64(size * 10), nil
+}
+
+func TestL1FeeHandlerServeHttp(t *testing.T) {
+	tests := []struct {
+		query  string
+		code   int
+		expect string
+	}{
+		{"?size=100", http.StatusOK, "1000"},
+		{"?tx=0x010203", http.StatusOK, "3"},
+		{"?tx=0x010203&size=100", http.StatusOK, "3"},
+		{"?tx=xyz", http.StatusBadRequest, ""},
+		{"?size=-1", http.StatusBadRequest, ""},
+		{"?size=131073", http.StatusBadRequest, ""},
+		{"?size=7", http.StatusNotImplemented, ""},
+		{"", http.StatusBadRequest, ""},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			estimator := estimatorMock{big.NewInt(32), big.NewInt(64)}
+			handler := NewL1Fee(estimator, l1FeeOracleMock{}, []string{"low", "high"})
+			r := httptest.NewRequest(http.MethodGet, "/v1/l1fee"+test.query, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != test.code {
+				t.Fatalf("status code should be %d but it is %d", test.code, w.Code)
+			}
+			if test.code != http.StatusOK {
+				return
+			}
+			var result l1FeeResponse
+			json.NewDecoder(w.Body).Decode(&result)
+			if result.L1Fee.value != test.expect {
+				t.Errorf("l1 fee should be %s but it is %s", test.expect, result.L1Fee.value)
+			}
+			if !reflect.DeepEqual(amounts(map[string]string{"low": "32", "high": "64"}), result.Prices) {
+				t.Error("response prices are not correct")
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// LegacyHandler serves the estimates in the original flat shape, mapping each
+// tier to its price in wei, for the clients written before the response
+// reported the block. The current shape is served by Handler.
+type LegacyHandler struct {
+	estimator Estimator
+	names     []string
+}
+
+func NewLegacy(estimator Estimator, names []string) *LegacyHandler {
+	return &LegacyHandler{estimator, names}
+}
+
+func (h *LegacyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	estimate, err := h.estimator.GasPrices(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not get gas price", "err", err)
+		return
+	}
+
+	results := make(map[string]string)
+	n := len(h.names)
+	if n > len(estimate.Prices) {
+		n = len(estimate.Prices)
+	}
+	for i := 0; i < n; i++ {
+		results[h.names[i]] = estimate.Prices[i].String()
+	}
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		slog.WarnContext(r.Context(), "could not encode prices", "err", err)
+	}
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLegacyHandlerServeHttp(t *testing.T) {
+	handler := NewLegacy(estimatorMock{big.NewInt(32)}, []string{"low", "medium"})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code should be %d but it is %d", http.StatusOK, w.Code)
+	}
+
+	var results map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatal("could not decode response:", err)
+	}
+	if len(results) != 1 || results["low"] != "32" {
+		t.Fatalf("response should be the flat tiers but it is %v", results)
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+)
+
+var ErrBadQuery = errors.New("percentiles or bands is invalid")
+
+// BandEstimator is implemented by estimators that can compute arbitrary
+// percentiles and bands from the sample of the current head.
+type BandEstimator interface {
+	Bands(ctx context.Context, targets []gasprice.Target) (gasprice.Estimate, error)
+	Percentiles(ctx context.Context, percentiles []float64) (gasprice.Estimate, error)
+}
+
+// estimate returns the estimate asked by the percentiles or bands query
+// parameters along with the names of its prices, or the default tiers if
+// neither is set.
+func (h *Handler) estimate(ctx context.Context, query url.Values) (gasprice.Estimate, []string, error) {
+	rawPercentiles := query.Get("percentiles")
+	rawBands := query.Get("bands")
+	if rawPercentiles == "" && rawBands == "" {
+		estimate, err := h.estimator.GasPrices(ctx)
+		return estimate, h.names, err
+	}
+	estimator, ok := h.estimator.(BandEstimator)
+	if !ok || (rawPercentiles != "" && rawBands != "") {
+		return gasprice.Estimate{}, nil, ErrBadQuery
+	}
+
+	var estimate gasprice.Estimate
+	var names []string
+	var err error
+	if rawPercentiles != "" {
+		var percentiles []float64
+		names, percentiles, err = parsePercentiles(rawPercentiles)
+		if err != nil {
+			return gasprice.Estimate{}, nil, err
+		}
+		estimate, err = estimator.Percentiles(ctx, percentiles)
+	} else {
+		var targets []gasprice.Target
+		names, targets, err = parseBands(rawBands)
+		if err != nil {
+			return gasprice.Estimate{}, nil, err
+		}
+		estimate, err = estimator.Bands(ctx, targets)
+	}
+	if errors.Is(err, gasprice.ErrBadPercentiles) || errors.Is(err, gasprice.ErrBadTargets) {
+		return gasprice.Estimate{}, nil, ErrBadQuery
+	}
+	return estimate, names, err
+}
+
+func parsePercentiles(raw string) ([]string, []float64, error) {
+	names := strings.Split(raw, ",")
+	percentiles := make([]float64, len(names))
+	for i, name := range names {
+		p, err := strconv.ParseFloat(name, 64)
+		if err != nil {
+			return nil, nil, ErrBadQuery
+		}
+		percentiles[i] = p
+	}
+	return names, percentiles, nil
+}
+
+func parseBands(raw string) ([]string, []gasprice.Target, error) {
+	names := strings.Split(raw, ",")
+	targets := make([]gasprice.Target, len(names))
+	for i, name := range names {
+		rawStart, rawEnd, ok := strings.Cut(name, "-")
+		if !ok {
+			return nil, nil, ErrBadQuery
+		}
+		start, err := strconv.ParseFloat(rawStart, 64)
+		if err != nil {
+			return nil, nil, ErrBadQuery
+		}
+		end, err := strconv.ParseFloat(rawEnd, 64)
+		if err != nil {
+			return nil, nil, ErrBadQuery
+		}
+		targets[i] = gasprice.Target{Start: start, End: end}
+	}
+	return names, targets, nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+)
+
+type bandEstimatorMock struct {
+	estimatorMock
+}
+
+func (e bandEstimatorMock) Bands(ctx context.Context, targets []gasprice.Target) (gasprice.Estimate, error) {
+	prices := make([]*big.Int, len(targets))
+	for i, t := range targets {
+		if t.End <= t.Start {
+			return gasprice.Estimate{}, gasprice.ErrBadTargets
+		}
+		prices[i] = big.NewInt(int64(t.End * 100))
+	}
+	return gasprice.Estimate{Block: blockMock, Latest: latestMock, Prices: prices}, nil
+}
+
+func (e bandEstimatorMock) Percentiles(ctx context.Context, percentiles []float64) (gasprice.Estimate, error) {
+	prices := make([]*big.Int, len(percentiles))
+	for i, p := range percentiles {
+		if p > 100 {
+			return gasprice.Estimate{}, gasprice.ErrBadPercentiles
+		}
+		prices[i] = big.NewInt(int64(p))
+	}
+	return gasprice.Estimate{Block: blockMock, Latest: latestMock, Prices: prices}, nil
+}
+
+func TestHandlerServeHttpQuery(t *testing.T) {
+	tests := []struct {
+		estimator Estimator
+		query     string
+		code      int
+		expect    map[string]string
+	}{
+		{bandEstimatorMock{estimatorMock{big.NewInt(32)}}, "", http.StatusOK, map[string]string{"low": "32"}},
+		{bandEstimatorMock{}, "?percentiles=10,50,90", http.StatusOK, map[string]string{"10": "10", "50": "50", "90": "90"}},
+		{bandEstimatorMock{}, "?bands=0-0.3,0.3-1", http.StatusOK, map[string]string{"0-0.3": "30", "0.3-1": "100"}},
+		{bandEstimatorMock{}, "?percentiles=101", http.StatusBadRequest, nil},
+		{bandEstimatorMock{}, "?percentiles=a", http.StatusBadRequest, nil},
+		{bandEstimatorMock{}, "?bands=0.5-0.2", http.StatusBadRequest, nil},
+		{bandEstimatorMock{}, "?bands=0.5", http.StatusBadRequest, nil},
+		{bandEstimatorMock{}, "?bands=0-1&percentiles=50", http.StatusBadRequest, nil},
+		{estimatorMock{big.NewInt(32)}, "?percentiles=50", http.StatusBadRequest, nil},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			handler := Handler{test.estimator, []string{"low"}, nil, 0}
+			r := httptest.NewRequest(http.MethodGet, "/"+test.query, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != test.code {
+				t.Fatalf("status code should be %d but it is %d", test.code, w.Code)
+			}
+			if test.code != http.StatusOK {
+				return
+			}
+			var result response
+			json.NewDecoder(w.Body).Decode(&result)
+			if !reflect.DeepEqual(amounts(test.expect), result.Prices) {
+				t.Errorf("response prices are not correct: %v", result.Prices)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// maxQuoteBody bounds the request body, which fits the calldata of the
+// largest transaction hex encoded.
+const maxQuoteBody = 1 << 20
+
+// gasTimeout bounds the time the provider has to estimate the gas.
+var gasTimeout = 10 * time.Second
+
+type GasEstimator interface {
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+}
+
+type QuoteHandler struct {
+	estimator    Estimator
+	gasEstimator GasEstimator
+	names        []string
+}
+
+func NewQuote(estimator Estimator, gasEstimator GasEstimator, names []string) *QuoteHandler {
+	return &QuoteHandler{estimator, gasEstimator, names}
+}
+
+type quoteTx struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Data  hexutil.Bytes   `json:"data"`
+}
+
+type quoteRequest struct {
+	Gas *hexutil.Uint64 `json:"gas"`
+	Tx  *quoteTx        `json:"tx"`
+}
+
+type costResponse struct {
+	Wei  amount `json:"wei"`
+	Gwei amount `json:"gwei"`
+}
+
+type quoteResponse struct {
+	Block       blockResponse           `json:"block"`
+	Gas         uint64                  `json:"gas"`
+	NextBaseFee *amount                 `json:"nextBaseFee,omitempty"`
+	Costs       map[string]costResponse `json:"costs"`
+}
+
+// costs returns the cost of the gas for each of the first n tiers. A price
+// below the next base fee could not be included, so such tiers pay the next
+// base fee instead.
+func costs(estimate gasprice.Estimate, gas uint64, n int) []*big.Int {
+	if n > len(estimate.Prices) {
+		n = len(estimate.Prices)
+	}
+	results := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		price := estimate.Prices[i]
+		if estimate.NextBaseFee != nil && price.Cmp(estimate.NextBaseFee) == -1 {
+			price = estimate.NextBaseFee
+		}
+		results[i] = new(big.Int).Mul(price, new(big.Int).SetUint64(gas))
+	}
+	return results
+}
+
+func (h *QuoteHandler) gas(ctx context.Context, req quoteRequest) (uint64, error) {
+	if req.Gas != nil {
+		return uint64(*req.Gas), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, gasTimeout)
+	defer cancel()
+	msg := ethereum.CallMsg{
+		From:  req.Tx.From,
+		To:    req.Tx.To,
+		Value: (*big.Int)(req.Tx.Value),
+		Data:  req.Tx.Data,
+	}
+	return h.gasEstimator.EstimateGas(ctx, msg)
+}
+
+func (h *QuoteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	f, err := parseFormat(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	var req quoteRequest
+	body := http.MaxBytesReader(w, r.Body, maxQuoteBody)
+	err = json.NewDecoder(body).Decode(&req)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "request too large")
+		return
+	}
+	if err != nil || (req.Gas == nil && req.Tx == nil) {
+		writeError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	gas, err := h.gas(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "could not estimate gas")
+		slog.ErrorContext(r.Context(), "could not estimate gas", "err", err)
+		return
+	}
+
+	estimate, err := h.estimator.GasPrices(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not get gas price", "err", err)
+		return
+	}
+
+	resp := quoteResponse{
+		Block: newBlockResponse(estimate.Block),
+		Gas:   gas,
+		Costs: make(map[string]costResponse),
+	}
+	if estimate.NextBaseFee != nil {
+		nextBaseFee := f.format(estimate.NextBaseFee)
+		resp.NextBaseFee = &nextBaseFee
+	}
+	for i, cost := range costs(estimate, gas, len(h.names)) {
+		resp.Costs[h.names[i]] = costResponse{
+			f.withUnit("wei").format(cost),
+			f.withUnit("gwei").format(cost),
+		}
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.WarnContext(r.Context(), "could not encode quote", "err", err)
+	}
+}
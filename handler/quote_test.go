@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+)
+
+type gasEstimatorMock struct{}
+
+func (g gasEstimatorMock) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if len(msg.Data) == 0 {
+		return 0, errors.New("execution reverted")
+	}
+	// a stuck provider
+	if msg.Data[0] == 0xff {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	return 21000 + uint64(len(msg.Data))*16, nil
+}
+
+type baseFeeEstimatorMock []*big.Int
+
+func (e baseFeeEstimatorMock) GasPrices(ctx context.Context) (gasprice.Estimate, error) {
+	return gasprice.Estimate{Block: blockMock, Prices: e, NextBaseFee: big.NewInt(20_000_000_000)}, nil
+}
+
+func TestQuoteHandlerServeHttp(t *testing.T) {
+	tests := []struct {
+		method string
+		body   string
+		code   int
+		expect map[string]costResponse
+	}{
+		{
+			http.MethodPost,
+			`{"gas": "0x5208"}`,
+			http.StatusOK,
+			map[string]costResponse{
+				"low":  {amount{"420000000000000", false}, amount{"420000", false}},
+				"high": {amount{"630000000000000", false}, amount{"630000", false}},
+			},
+		},
+		{
+			http.MethodPost,
+			`{"tx": {"to": "0x000000000000000000000000000000000000dead", "data": "0x01"}}`,
+			http.StatusOK,
+			map[string]costResponse{
+				"low":  {amount{"420320000000000", false}, amount{"420320", false}},
+				"high": {amount{"630480000000000", false}, amount{"630480", false}},
+			},
+		},
+		{http.MethodPost, `{"tx": {"to": "0x000000000000000000000000000000000000dead"}}`, http.StatusUnprocessableEntity, nil},
+		{http.MethodPost, `{}`, http.StatusBadRequest, nil},
+		{http.MethodPost, `not json`, http.StatusBadRequest, nil},
+		{http.MethodPost, `{"tx": {"to": "0x000000000000000000000000000000000000dead", "data": "0xff"}}`, http.StatusUnprocessableEntity, nil},
+		{http.MethodPost, `{"gas": "0x5208", "pad": "` + strings.Repeat("0", maxQuoteBody) + `"}`, http.StatusRequestEntityTooLarge, nil},
+		{http.MethodGet, ``, http.StatusMethodNotAllowed, nil},
+	}
+
+	defer func(timeout time.Duration) { gasTimeout = timeout }(gasTimeout)
+	gasTimeout = 10 * time.Millisecond
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			estimator := baseFeeEstimatorMock{big.NewInt(10_000_000_000), big.NewInt(30_000_000_000)}
+			handler := NewQuote(estimator, gasEstimatorMock{}, []string{"low", "high"})
+			r := httptest.NewRequest(test.method, "/v1/quote", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != test.code {
+				t.Fatalf("status code should be %d but it is %d", test.code, w.Code)
+			}
+			if test.code != http.StatusOK {
+				return
+			}
+			var result quoteResponse
+			json.NewDecoder(w.Body).Decode(&result)
+			if !reflect.DeepEqual(test.expect, result.Costs) {
+				t.Errorf("response costs are not correct: %v", result.Costs)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+type Subscriber interface {
+	Subscribe() (<-chan gasprice.Estimate, func())
+}
+
+// StreamHandler streams the responses of Handler as server-sent events, one
+// prices event for the current estimate and one for every new estimate, with
+// the block hash as their id. The current estimate is skipped if it is the
+// one of the Last-Event-ID header.
+type StreamHandler struct {
+	handler    *Handler
+	subscriber Subscriber
+}
+
+func NewStream(handler *Handler, subscriber Subscriber) *StreamHandler {
+	return &StreamHandler{handler, subscriber}
+}
+
+func (h *StreamHandler) send(ctx context.Context, w http.ResponseWriter, f amountFormat, estimate gasprice.Estimate) error {
+	data, err := json.Marshal(h.handler.newResponse(ctx, f, h.handler.names, estimate, 0))
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("id: " + estimate.Block.Hash.Hex() + "\nevent: prices\ndata: ")); err != nil {
+		return err
+	}
+	if _, err := w.Write(append(data, '\n', '\n')); err != nil {
+		return err
+	}
+	w.(http.Flusher).Flush()
+	return nil
+}
+
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFormat(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+	if _, ok := w.(http.Flusher); !ok {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not stream prices, response can not be flushed")
+		return
+	}
+
+	ch, unsubscribe := h.subscriber.Subscribe()
+	defer unsubscribe()
+	estimate, err := h.handler.estimator.GasPrices(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		slog.ErrorContext(r.Context(), "could not get gas price", "err", err)
+		return
+	}
+
+	// a reconnecting client already has the estimate of the last event
+	var last common.Hash
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		last = common.HexToHash(id)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	if estimate.Block.Hash != last {
+		if err := h.send(r.Context(), w, f, estimate); err != nil {
+			return
+		}
+		last = estimate.Block.Hash
+	} else {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+	}
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case estimate := <-ch:
+			if estimate.Block.Hash == last {
+				continue
+			}
+			last = estimate.Block.Hash
+			if err := h.send(r.Context(), w, f, estimate); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := w.Write([]byte(": heartbeat\n\n")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
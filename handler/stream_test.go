@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type subscriberMock chan gasprice.Estimate
+
+func (s subscriberMock) Subscribe() (<-chan gasprice.Estimate, func()) {
+	return s, func() {}
+}
+
+func readEvent(t *testing.T, reader *bufio.Reader) response {
+	var resp response
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal("could not read event:", err)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			if err := json.Unmarshal([]byte(data), &resp); err != nil {
+				t.Fatal("could not decode event:", err)
+			}
+			return resp
+		}
+	}
+}
+
+func TestStreamServeHttp(t *testing.T) {
+	ch := make(subscriberMock, 1)
+	handler := New(estimatorMock{big.NewInt(32)}, []string{"low"}, nil, 0)
+	server := httptest.NewServer(NewStream(handler, ch))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?unit=gwei", nil)
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal("could not connect:", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatal("stream has wrong content type")
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if event := readEvent(t, reader); event.Prices["low"].value != "0.000000032" {
+		t.Fatalf("first event is not correct: %v", event.Prices)
+	}
+	block := gasprice.Block{Number: 43, Hash: common.HexToHash("0x2b")}
+	ch <- gasprice.Estimate{Block: block, Latest: block, Prices: []*big.Int{big.NewInt(64)}}
+	if event := readEvent(t, reader); event.Block.Number != 43 || event.Prices["low"].value != "0.000000064" {
+		t.Fatalf("second event is not correct: %v", event)
+	}
+}
+
+func TestStreamServeHttpResume(t *testing.T) {
+	ch := make(subscriberMock, 1)
+	handler := New(estimatorMock{big.NewInt(32)}, []string{"low"}, nil, 0)
+	server := httptest.NewServer(NewStream(handler, ch))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	r.Header.Set("Last-Event-ID", blockMock.Hash.Hex())
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal("could not connect:", err)
+	}
+	defer resp.Body.Close()
+
+	block := gasprice.Block{Number: 43, Hash: common.HexToHash("0x2b")}
+	ch <- gasprice.Estimate{Block: block, Latest: block, Prices: []*big.Int{big.NewInt(64)}}
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "id: "+block.Hash.Hex()+"\n" {
+		t.Fatalf("event has wrong id line %q", line)
+	}
+	if event := readEvent(t, reader); event.Block.Number != 43 {
+		t.Fatalf("the estimate of the last event was sent again: %v", event)
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ArmanMazdaee/yaegpe/alert"
+	"github.com/ArmanMazdaee/yaegpe/auth"
+)
+
+// maxWebhookBody bounds the request body, which is a few short fields.
+const maxWebhookBody = 1 << 16
+
+type WebhookRegistry interface {
+	Add(ctx context.Context, owner string, webhook alert.Webhook) (alert.Webhook, error)
+	List(owner string) []alert.Webhook
+	Remove(owner string, id string) error
+}
+
+type WebhookHandler struct {
+	registry WebhookRegistry
+}
+
+func NewWebhooks(registry WebhookRegistry) *WebhookHandler {
+	return &WebhookHandler{registry}
+}
+
+// ServeHTTP lists the webhooks on GET, registers one on POST and removes the
+// one given by the id query parameter on DELETE. Each API key only sees the
+// webhooks it registered.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	owner := auth.KeyName(r.Context())
+	if owner == "" {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if err := json.NewEncoder(w).Encode(h.registry.List(owner)); err != nil {
+			slog.WarnContext(r.Context(), "could not encode webhooks", "err", err)
+		}
+	case http.MethodPost:
+		var webhook alert.Webhook
+		body := http.MaxBytesReader(w, r.Body, maxWebhookBody)
+		err := json.NewDecoder(body).Decode(&webhook)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request too large")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad request")
+			return
+		}
+		webhook, err = h.registry.Add(r.Context(), owner, webhook)
+		if errors.Is(err, alert.ErrBadWebhook) || errors.Is(err, alert.ErrForbiddenAddress) {
+			writeError(w, http.StatusBadRequest, "bad request")
+			return
+		}
+		if errors.Is(err, alert.ErrTooManyWebhooks) {
+			writeError(w, http.StatusConflict, "too many webhooks")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			slog.ErrorContext(r.Context(), "could not add webhook", "err", err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(webhook); err != nil {
+			slog.WarnContext(r.Context(), "could not encode webhook", "err", err)
+		}
+	case http.MethodDelete:
+		err := h.registry.Remove(owner, r.URL.Query().Get("id"))
+		if errors.Is(err, alert.ErrNoWebhook) {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			slog.ErrorContext(r.Context(), "could not remove webhook", "err", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
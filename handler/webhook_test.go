@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ArmanMazdaee/yaegpe/alert"
+	"github.com/ArmanMazdaee/yaegpe/auth"
+)
+
+func TestWebhookServeHttp(t *testing.T) {
+	registry, err := alert.NewRegistry(filepath.Join(t.TempDir(), "webhooks.json"), []string{"low"})
+	if err != nil {
+		t.Fatal("could not create registry:", err)
+	}
+	handler := NewWebhooks(registry)
+
+	body := `{"url":"https://1.1.1.1/hook","tier":"low","condition":"below","threshold":"1000000000","cooldown":600}`
+	r := httptest.NewRequest(http.MethodPost, "/v1/webhooks", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status code should be %d without a key but it is %d", http.StatusUnauthorized, w.Code)
+	}
+
+	partner := auth.WithKeyName(context.Background(), "partner")
+	r = httptest.NewRequest(http.MethodPost, "/v1/webhooks", strings.NewReader(body)).WithContext(partner)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status code should be %d but it is %d", http.StatusCreated, w.Code)
+	}
+	var created alert.Webhook
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.ID == "" || created.Secret == "" || created.Cooldown != 600 {
+		t.Fatalf("created webhook is not correct: %v", created)
+	}
+
+	other := auth.WithKeyName(context.Background(), "other")
+	tests := []struct {
+		ctx    context.Context
+		method string
+		target string
+		body   string
+		code   int
+	}{
+		{partner, http.MethodPost, "/v1/webhooks", `{"url":"https://1.1.1.1/hook","tier":"high","condition":"below","threshold":"1"}`, http.StatusBadRequest},
+		{partner, http.MethodPost, "/v1/webhooks", `{"url":"http://127.0.0.1/hook","tier":"low","condition":"below","threshold":"1"}`, http.StatusBadRequest},
+		{partner, http.MethodPost, "/v1/webhooks", `{`, http.StatusBadRequest},
+		{partner, http.MethodPost, "/v1/webhooks", `{"url":"` + strings.Repeat("a", maxWebhookBody) + `"}`, http.StatusRequestEntityTooLarge},
+		{partner, http.MethodGet, "/v1/webhooks", "", http.StatusOK},
+		{other, http.MethodDelete, "/v1/webhooks?id=" + created.ID, "", http.StatusNotFound},
+		{partner, http.MethodDelete, "/v1/webhooks?id=" + created.ID, "", http.StatusNoContent},
+		{partner, http.MethodDelete, "/v1/webhooks?id=" + created.ID, "", http.StatusNotFound},
+		{partner, http.MethodPut, "/v1/webhooks", "", http.StatusMethodNotAllowed},
+	}
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)).WithContext(test.ctx)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != test.code {
+				t.Errorf("status code should be %d but it is %d", test.code, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Record is the estimate of a block as it is stored, with the base fees of
+// the block itself.
+type Record struct {
+	Number      uint64
+	Hash        common.Hash
+	Time        uint64
+	BaseFee     *big.Int
+	BlobBaseFee *big.Int
+	Prices      []*big.Int
+}
+
+func NewRecord(estimate gasprice.Estimate) Record {
+	return Record{
+		estimate.Block.Number,
+		estimate.Block.Hash,
+		estimate.Block.Time,
+		estimate.BaseFee,
+		estimate.BlobBaseFee,
+		estimate.Prices,
+	}
+}
+
+// Store persists records. Range returns the ones with a timestamp between
+// from and to, inclusive, ordered by time, Aggregate returns their points in
+// intervals of resolution seconds like the Aggregate function and Prune
+// deletes the ones older than before.
+type Store interface {
+	Save(ctx context.Context, record Record) error
+	Range(ctx context.Context, from uint64, to uint64) ([]Record, error)
+	Aggregate(ctx context.Context, from uint64, to uint64, resolution uint64) ([]Point, error)
+	Prune(ctx context.Context, before uint64) error
+}
+
+var pruneInterval = time.Hour
+
+type Subscriber interface {
+	Subscribe() (<-chan gasprice.Estimate, func())
+}
+
+// Run saves every new estimate of the subscriber in the store until the
+// context is done, pruning the records older than the retention every hour
+// unless it is zero.
+func Run(ctx context.Context, subscriber Subscriber, store Store, retention time.Duration) {
+	ch, unsubscribe := subscriber.Subscribe()
+	defer unsubscribe()
+	var tick <-chan time.Time
+	if retention != 0 {
+		prune(ctx, store, time.Now().Add(-retention))
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case estimate := <-ch:
+			if err := store.Save(ctx, NewRecord(estimate)); err != nil {
+				slog.ErrorContext(ctx, "could not save estimate", "err", err)
+			}
+		case now := <-tick:
+			prune(ctx, store, now.Add(-retention))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func prune(ctx context.Context, store Store, before time.Time) {
+	if err := store.Prune(ctx, uint64(before.Unix())); err != nil {
+		slog.ErrorContext(ctx, "could not prune history", "err", err)
+	}
+}
+
+// Point aggregates the records of one interval. The base fees and prices are
+// averaged over the records having them.
+type Point struct {
+	Time       uint64
+	Blocks     int
+	BaseFee    *big.Int
+	MinBaseFee *big.Int
+	MaxBaseFee *big.Int
+	Prices     []*big.Int
+}
+
+// Aggregate groups the records ordered by time into intervals of resolution
+// seconds starting from from. Intervals without records are left out.
+func Aggregate(records []Record, from uint64, resolution uint64) []Point {
+	points := make([]Point, 0)
+	first := 0
+	for i, record := range records {
+		if record.Time < from {
+			first = i + 1
+			continue
+		}
+		start := from + (record.Time-from)/resolution*resolution
+		if i+1 < len(records) && records[i+1].Time < start+resolution {
+			continue
+		}
+		points = append(points, aggregate(start, records[first:i+1]))
+		first = i + 1
+	}
+	return points
+}
+
+func aggregate(start uint64, records []Record) Point {
+	point := Point{Time: start, Blocks: len(records)}
+
+	baseFees := new(big.Int)
+	var count int64
+	for _, record := range records {
+		if record.BaseFee == nil {
+			continue
+		}
+		baseFees.Add(baseFees, record.BaseFee)
+		count++
+		if point.MinBaseFee == nil || record.BaseFee.Cmp(point.MinBaseFee) < 0 {
+			point.MinBaseFee = record.BaseFee
+		}
+		if point.MaxBaseFee == nil || record.BaseFee.Cmp(point.MaxBaseFee) > 0 {
+			point.MaxBaseFee = record.BaseFee
+		}
+	}
+	if count > 0 {
+		point.BaseFee = baseFees.Div(baseFees, big.NewInt(count))
+	}
+
+	for tier := 0; ; tier++ {
+		sum := new(big.Int)
+		count = 0
+		for _, record := range records {
+			if tier < len(record.Prices) {
+				sum.Add(sum, record.Prices[tier])
+				count++
+			}
+		}
+		if count == 0 {
+			break
+		}
+		point.Prices = append(point.Prices, sum.Div(sum, big.NewInt(count)))
+	}
+	return point
+}
@@ -0,0 +1,131 @@
+package history
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func newRecord(number uint64, time uint64, baseFee int64, prices ...int64) Record {
+	record := Record{Number: number, Hash: common.BigToHash(new(big.Int).SetUint64(number)), Time: time}
+	if baseFee != 0 {
+		record.BaseFee = big.NewInt(baseFee)
+	}
+	for _, p := range prices {
+		record.Prices = append(record.Prices, big.NewInt(p))
+	}
+	return record
+}
+
+func TestAggregate(t *testing.T) {
+	records := []Record{
+		newRecord(1, 90, 10, 1, 2),
+		newRecord(2, 100, 10, 10, 20),
+		newRecord(3, 112, 20, 20, 40),
+		newRecord(4, 124, 0, 30),
+		newRecord(5, 160, 30, 40, 80),
+	}
+	expected := []Point{
+		{100, 3, big.NewInt(15), big.NewInt(10), big.NewInt(20), []*big.Int{big.NewInt(20), big.NewInt(30)}},
+		{160, 1, big.NewInt(30), big.NewInt(30), big.NewInt(30), []*big.Int{big.NewInt(40), big.NewInt(80)}},
+	}
+
+	points := Aggregate(records, 100, 30)
+	if !reflect.DeepEqual(points, expected) {
+		t.Fatalf("Aggregate returned %v but expected %v", points, expected)
+	}
+}
+
+func TestStores(t *testing.T) {
+	sqlite, err := NewSQLite(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal("could not create sqlite store:", err)
+	}
+	defer sqlite.Close()
+	stores := []Store{NewMemory(100), sqlite}
+
+	for i, store := range stores {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			ctx := context.Background()
+			records := []Record{
+				newRecord(3, 36, 30, 3, 6),
+				newRecord(1, 12, 10, 1, 2),
+				newRecord(2, 24, 0),
+				newRecord(3, 36, 30, 4, 8),
+			}
+			for _, record := range records {
+				if err := store.Save(ctx, record); err != nil {
+					t.Fatal("Save returned error:", err)
+				}
+			}
+
+			result, err := store.Range(ctx, 20, 40)
+			if err != nil {
+				t.Fatal("Range returned error:", err)
+			}
+			expected := []Record{records[2], records[3]}
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("Range returned %v but expected %v", result, expected)
+			}
+
+			result, err = store.Range(ctx, 40, 50)
+			if err != nil || len(result) != 0 {
+				t.Error("Range should return no records outside of the range")
+			}
+
+			points, err := store.Aggregate(ctx, 10, 40, 20)
+			if err != nil {
+				t.Fatal("Aggregate returned error:", err)
+			}
+			expectedPoints := Aggregate([]Record{records[1], records[2], records[3]}, 10, 20)
+			if !reflect.DeepEqual(points, expectedPoints) {
+				t.Errorf("Aggregate returned %v but expected %v", points, expectedPoints)
+			}
+
+			if err := store.Prune(ctx, 24); err != nil {
+				t.Fatal("Prune returned error:", err)
+			}
+			result, err = store.Range(ctx, 0, 50)
+			if err != nil {
+				t.Fatal("Range returned error:", err)
+			}
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("Range returned %v after pruning but expected %v", result, expected)
+			}
+		})
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemory(2)
+	records := []Record{
+		newRecord(2, 24, 20),
+		newRecord(1, 12, 10),
+		newRecord(3, 36, 30),
+		newRecord(2, 24, 25),
+		newRecord(4, 48, 40),
+	}
+	for _, record := range records {
+		if err := store.Save(ctx, record); err != nil {
+			t.Fatal("Save returned error:", err)
+		}
+	}
+
+	result, err := store.Range(ctx, 0, 50)
+	if err != nil {
+		t.Fatal("Range returned error:", err)
+	}
+	expected := []Record{records[2], records[4]}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Range returned %v but expected %v", result, expected)
+	}
+	if len(store.times) != 2 {
+		t.Errorf("Memory still indexes %d records", len(store.times))
+	}
+}
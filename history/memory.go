@@ -0,0 +1,94 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Memory keeps up to limit records in memory, for when nothing should be
+// persisted, dropping the oldest ones once it is full.
+type Memory struct {
+	records []Record
+	times   map[common.Hash]uint64
+	limit   int
+	lock    sync.RWMutex
+}
+
+func NewMemory(limit int) *Memory {
+	return &Memory{make([]Record, 0), make(map[common.Hash]uint64), limit, sync.RWMutex{}}
+}
+
+// search returns the index of the first record newer than time, or at time
+// if inclusive.
+func (m *Memory) search(time uint64, inclusive bool) int {
+	return sort.Search(len(m.records), func(i int) bool {
+		if inclusive {
+			return m.records[i].Time >= time
+		}
+		return m.records[i].Time > time
+	})
+}
+
+func (m *Memory) Save(ctx context.Context, record Record) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	// a saved block is replaced among the records of its time
+	if time, ok := m.times[record.Hash]; ok {
+		for i := m.search(time, true); i < len(m.records) && m.records[i].Time == time; i++ {
+			if m.records[i].Hash == record.Hash {
+				m.records = append(m.records[:i], m.records[i+1:]...)
+				break
+			}
+		}
+	}
+
+	i := m.search(record.Time, false)
+	m.records = append(m.records, Record{})
+	copy(m.records[i+1:], m.records[i:])
+	m.records[i] = record
+	m.times[record.Hash] = record.Time
+	if len(m.records) > m.limit {
+		m.drop(len(m.records) - m.limit)
+	}
+	return nil
+}
+
+// drop deletes the n oldest records, leaving their space to be reclaimed when
+// the records grow instead of moving the others.
+func (m *Memory) drop(n int) {
+	for i := range m.records[:n] {
+		delete(m.times, m.records[i].Hash)
+		m.records[i] = Record{}
+	}
+	m.records = m.records[n:]
+}
+
+func (m *Memory) Range(ctx context.Context, from uint64, to uint64) ([]Record, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	first := m.search(from, true)
+	last := m.search(to, false)
+	records := make([]Record, 0)
+	if first < last {
+		records = append(records, m.records[first:last]...)
+	}
+	return records, nil
+}
+
+func (m *Memory) Aggregate(ctx context.Context, from uint64, to uint64, resolution uint64) ([]Point, error) {
+	records, err := m.Range(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return Aggregate(records, from, resolution), nil
+}
+
+func (m *Memory) Prune(ctx context.Context, before uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.drop(m.search(before, true))
+	return nil
+}
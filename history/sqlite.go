@@ -0,0 +1,253 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	_ "modernc.org/sqlite"
+)
+
+var ErrBadRecord = errors.New("stored record is invalid")
+var ErrBadAmount = errors.New("amount does not fit in an integer column")
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS estimates (
+	hash TEXT PRIMARY KEY,
+	number INTEGER NOT NULL,
+	time INTEGER NOT NULL,
+	base_fee INTEGER,
+	blob_base_fee INTEGER
+);
+CREATE INDEX IF NOT EXISTS estimates_time ON estimates (time);
+CREATE TABLE IF NOT EXISTS prices (
+	hash TEXT NOT NULL,
+	tier INTEGER NOT NULL,
+	price INTEGER NOT NULL,
+	PRIMARY KEY (hash, tier)
+);
+`
+
+// SQLite stores the records in a SQLite database, with the amounts as
+// integers so they can be aggregated by the database, and the prices of the
+// tiers in their own table.
+type SQLite struct {
+	db *sql.DB
+}
+
+func NewSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows a single writer
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLite{db}, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func formatAmount(x *big.Int) (sql.NullInt64, error) {
+	if x == nil {
+		return sql.NullInt64{}, nil
+	}
+	if !x.IsInt64() {
+		return sql.NullInt64{}, ErrBadAmount
+	}
+	return sql.NullInt64{Int64: x.Int64(), Valid: true}, nil
+}
+
+func parseAmount(x sql.NullInt64) *big.Int {
+	if !x.Valid {
+		return nil
+	}
+	return big.NewInt(x.Int64)
+}
+
+func (s *SQLite) Save(ctx context.Context, record Record) error {
+	baseFee, err := formatAmount(record.BaseFee)
+	if err != nil {
+		return err
+	}
+	blobBaseFee, err := formatAmount(record.BlobBaseFee)
+	if err != nil {
+		return err
+	}
+	prices := make([]int64, len(record.Prices))
+	for i, price := range record.Prices {
+		if !price.IsInt64() {
+			return ErrBadAmount
+		}
+		prices[i] = price.Int64()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	hash := record.Hash.Hex()
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT OR REPLACE INTO estimates (hash, number, time, base_fee, blob_base_fee) VALUES (?, ?, ?, ?, ?)",
+		hash,
+		int64(record.Number),
+		int64(record.Time),
+		baseFee,
+		blobBaseFee,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM prices WHERE hash = ?", hash); err != nil {
+		return err
+	}
+	for tier, price := range prices {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO prices (hash, tier, price) VALUES (?, ?, ?)", hash, tier, price); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) Range(ctx context.Context, from uint64, to uint64) ([]Record, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT e.hash, e.number, e.time, e.base_fee, e.blob_base_fee, p.tier, p.price
+		FROM estimates e LEFT JOIN prices p ON p.hash = e.hash
+		WHERE e.time >= ? AND e.time <= ?
+		ORDER BY e.time, e.number, e.hash, p.tier`,
+		int64(from),
+		int64(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]Record, 0)
+	for rows.Next() {
+		var hash string
+		var number, time int64
+		var baseFee, blobBaseFee, tier, price sql.NullInt64
+		if err := rows.Scan(&hash, &number, &time, &baseFee, &blobBaseFee, &tier, &price); err != nil {
+			return nil, err
+		}
+
+		n := len(records)
+		if n == 0 || records[n-1].Hash != common.HexToHash(hash) {
+			records = append(records, Record{
+				uint64(number),
+				common.HexToHash(hash),
+				uint64(time),
+				parseAmount(baseFee),
+				parseAmount(blobBaseFee),
+				nil,
+			})
+			n++
+		}
+		if tier.Valid {
+			if int(tier.Int64) != len(records[n-1].Prices) {
+				return nil, ErrBadRecord
+			}
+			records[n-1].Prices = append(records[n-1].Prices, parseAmount(price))
+		}
+	}
+	return records, rows.Err()
+}
+
+// Aggregate computes the points in the database instead of reading every
+// record, which the history of a busy chain has too many of.
+func (s *SQLite) Aggregate(ctx context.Context, from uint64, to uint64, resolution uint64) ([]Point, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT (time - ?1) / ?2 AS interval, COUNT(*), SUM(base_fee) / COUNT(base_fee), MIN(base_fee), MAX(base_fee)
+		FROM estimates
+		WHERE time >= ?1 AND time <= ?3
+		GROUP BY interval
+		ORDER BY interval`,
+		int64(from),
+		int64(resolution),
+		int64(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]Point, 0)
+	intervals := make(map[int64]int)
+	for rows.Next() {
+		var interval int64
+		var blocks int
+		var baseFee, minBaseFee, maxBaseFee sql.NullInt64
+		if err := rows.Scan(&interval, &blocks, &baseFee, &minBaseFee, &maxBaseFee); err != nil {
+			return nil, err
+		}
+		intervals[interval] = len(points)
+		points = append(points, Point{
+			from + uint64(interval)*resolution,
+			blocks,
+			parseAmount(baseFee),
+			parseAmount(minBaseFee),
+			parseAmount(maxBaseFee),
+			nil,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = s.db.QueryContext(
+		ctx,
+		`SELECT (e.time - ?1) / ?2 AS interval, p.tier, SUM(p.price) / COUNT(*)
+		FROM estimates e JOIN prices p ON p.hash = e.hash
+		WHERE e.time >= ?1 AND e.time <= ?3
+		GROUP BY interval, p.tier
+		ORDER BY interval, p.tier`,
+		int64(from),
+		int64(resolution),
+		int64(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var interval, tier, price int64
+		if err := rows.Scan(&interval, &tier, &price); err != nil {
+			return nil, err
+		}
+		i, ok := intervals[interval]
+		if !ok || int(tier) != len(points[i].Prices) {
+			return nil, ErrBadRecord
+		}
+		points[i].Prices = append(points[i].Prices, big.NewInt(price))
+	}
+	return points, rows.Err()
+}
+
+func (s *SQLite) Prune(ctx context.Context, before uint64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, "DELETE FROM prices WHERE hash IN (SELECT hash FROM estimates WHERE time < ?)", int64(before))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM estimates WHERE time < ?", int64(before)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
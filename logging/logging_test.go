@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		format string
+		level  string
+		ok     bool
+	}{
+		{"json", "info", true},
+		{"logfmt", "debug", true},
+		{"logfmt", "WARN", true},
+		{"text", "info", false},
+		{"json", "verbose", false},
+	}
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			_, err := New(&bytes.Buffer{}, test.format, test.level)
+			if (err == nil) != test.ok {
+				t.Fatalf("expected ok to be %v, got %v", test.ok, err)
+			}
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "logfmt", "warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", "number", 7)
+	if strings.Contains(buf.String(), "hidden") {
+		t.Fatal("info record should be dropped")
+	}
+	if !strings.Contains(buf.String(), "level=WARN msg=shown number=7") {
+		t.Fatalf("unexpected logs: %s", buf.String())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.ErrorContext(r.Context(), "failed")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("tea"))
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/v1/quote", nil)
+	r.Header.Set("X-Request-ID", "abc")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("X-Request-ID") != "abc" {
+		t.Fatalf("request id is %s", w.Header().Get("X-Request-ID"))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(lines))
+	}
+	var failed, access map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &failed); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &access); err != nil {
+		t.Fatal(err)
+	}
+	if failed["request_id"] != "abc" || access["request_id"] != "abc" {
+		t.Fatal("records should have the request id")
+	}
+	if access["status"] != float64(http.StatusTeapot) || access["bytes"] != float64(3) || access["path"] != "/v1/quote" {
+		t.Fatalf("unexpected access record: %s", lines[1])
+	}
+	if _, ok := access["latency"]; !ok {
+		t.Fatal("access record has no latency")
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if len(w.Header().Get("X-Request-ID")) != 16 {
+		t.Fatalf("expected a generated request id, got %s", w.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "logfmt", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), span)
+	logger.InfoContext(ctx, "traced")
+	if !strings.Contains(buf.String(), "trace_id="+span.TraceID().String()+" span_id="+span.SpanID().String()) {
+		t.Fatalf("unexpected logs: %s", buf.String())
+	}
+}
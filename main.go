@@ -4,14 +4,37 @@ import (
 	"context"
 	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"math/big"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/ArmanMazdaee/yaegpe/alert"
+	"github.com/ArmanMazdaee/yaegpe/api/yaegpev1"
+	"github.com/ArmanMazdaee/yaegpe/auth"
 	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ArmanMazdaee/yaegpe/grpcapi"
 	"github.com/ArmanMazdaee/yaegpe/handler"
+	"github.com/ArmanMazdaee/yaegpe/history"
+	"github.com/ArmanMazdaee/yaegpe/logging"
+	"github.com/ArmanMazdaee/yaegpe/pricefeed"
+	"github.com/ArmanMazdaee/yaegpe/replay"
+	"github.com/ArmanMazdaee/yaegpe/tracing"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
 )
 
 const sampleSize = 7
@@ -25,49 +48,451 @@ var estimatorTarget = []gasprice.Target{
 }
 var sampleMinPrice = big.NewInt(1e8)
 
+var blockTimes = map[string]time.Duration{
+	"ethereum": 12 * time.Second,
+	"optimism": 2 * time.Second,
+	"arbitrum": 250 * time.Millisecond,
+}
+
+const shutdownTimeout = 10 * time.Second
+
 func main() {
+	if err := run(); err != nil {
+		slog.Error("could not run", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run serves until an interrupt or a termination signal and then shuts the
+// servers down, waiting for their requests for a while.
+func run() error {
 	providerURL := flag.String("provider", "", "ethereum provider url")
 	addr := flag.String("addr", "0.0.0.0:8080", "server address")
+	grpcAddr := flag.String("grpc-addr", "", "grpc server address, or empty to disable it")
+	anchorTag := flag.String("anchor", "latest", "block tag to anchor estimates on (latest, safe or finalized)")
+	useReceipts := flag.Bool("receipts", false, "sample effective gas prices and gas used from receipts")
+	chain := flag.String("chain", "ethereum", "chain pricing mode (ethereum, optimism or arbitrum)")
+	blockTime := flag.Duration("block-time", 0, "expected time between blocks for cache headers, defaults to the one of the chain")
+	canyonTime := flag.Uint64("canyon-time", gasprice.OptimismCanyonTime, "time the optimism chain activated canyon, which changed its base fee parameters")
+	blobSchedulePath := flag.String("blob-schedule", "", "json file of the blob parameters by fork time, defaults to the one of the chain id")
+	historyPath := flag.String("history", "", "sqlite database to record estimates in, or empty to not record them")
+	historyRetention := flag.Duration("history-retention", 30*24*time.Hour, "how long to keep recorded estimates, or 0 to keep them forever")
+	webhooksPath := flag.String("webhooks", "", "file to persist webhooks in, or empty to disable them, which needs -api-keys")
+	recordPath := flag.String("record", "", "file to record the provider responses in")
+	replayPath := flag.String("replay", "", "recording to serve instead of connecting to a provider")
+	replaySpeed := flag.Float64("replay-speed", 1, "pace of the replayed blocks relative to the recording")
+	keysPath := flag.String("api-keys", "", "json file of the api keys to require, reloaded when it changes")
+	ipRate := flag.Float64("ip-rate", 0, "requests per second allowed per client ip, or 0 for no limit")
+	ipBurst := flag.Int("ip-burst", 20, "requests a client ip may make at once")
+	trustedProxies := flag.String("trusted-proxies", "", "comma separated cidrs of the proxies whose X-Forwarded-For is trusted")
+	corsOrigins := flag.String("cors-origins", "*", "comma separated origins browsers may call the api from, or * for any")
+	corsMethods := flag.String("cors-methods", "GET,POST,DELETE,OPTIONS", "comma separated methods browsers may use")
+	corsHeaders := flag.String("cors-headers", "Authorization,Content-Type,If-None-Match,X-API-Key", "comma separated headers browsers may send")
+	corsMaxAge := flag.Duration("cors-max-age", 10*time.Minute, "how long browsers may cache preflight responses")
+	logLevel := flag.String("log-level", "info", "lowest level of the logs (debug, info, warn or error)")
+	logFormat := flag.String("log-format", "logfmt", "format of the logs (logfmt or json)")
+	traceExporter := flag.String("trace-exporter", "", "exporter of the traces (stdout or otlp), or empty to not trace")
+	fiat := flag.String("fiat", "", "comma separated fiat price feeds such as USD=chainlink:0x...[:heartbeat],EUR=static:2800,GBP=file:prices.json")
 	flag.Parse()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	logger, err := logging.New(os.Stderr, *logFormat, *logLevel)
+	if err != nil {
+		return fmt.Errorf("could not create logger: %w", err)
+	}
+	slog.SetDefault(logger.With("provider", providerName(*providerURL, *replayPath)))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	provider, err := ethclient.Dial(*providerURL)
+	shutdown, err := tracing.Setup(ctx, *traceExporter, "yaegpe")
 	if err != nil {
-		log.Fatalln("could not connect to the provider: ", err)
+		return fmt.Errorf("could not set up tracing: %w", err)
+	}
+	defer shutdown(context.Background())
+
+	// the client is nil when replaying, which only supports what the
+	// recording covers
+	var client *ethclient.Client
+	var provider gasprice.Provider
+	var replayed *replay.Replay
+	if *replayPath != "" {
+		if *chain != "ethereum" || *useReceipts {
+			return errors.New("replay only supports the ethereum chain without receipts")
+		}
+		replayed, err = replay.NewReplay(*replayPath)
+		if err != nil {
+			return fmt.Errorf("could not load the recording: %w", err)
+		}
+		provider = replayed
+	} else {
+		client, err = ethclient.Dial(*providerURL)
+		if err != nil {
+			return fmt.Errorf("could not connect to the provider: %w", err)
+		}
+		defer client.Close()
+		provider = client
+	}
+	var batcher gasprice.Batcher
+	var receipts gasprice.ReceiptProvider
+	if client != nil {
+		batcher = client.Client()
+		if *useReceipts {
+			receipts = client
+		}
+	}
+	if *recordPath != "" && client != nil {
+		if *useReceipts {
+			return errors.New("receipts can not be recorded")
+		}
+		recorder, err := replay.NewRecorder(client, *recordPath)
+		if err != nil {
+			return fmt.Errorf("could not create the recording: %w", err)
+		}
+		defer recorder.Close()
+		// blocks are fetched one by one so the recorder sees them
+		provider = recorder
+		batcher = nil
+	}
+	provider = tracing.NewProvider(provider)
+	if batcher != nil {
+		batcher = tracing.NewBatcher(batcher)
+	}
+	if receipts != nil {
+		receipts = tracing.NewReceiptProvider(receipts)
+	}
+	var caller ethereum.ContractCaller
+	var gasEstimator ethereum.GasEstimator
+	if client != nil {
+		caller = tracing.NewContractCaller(client)
+		gasEstimator = tracing.NewGasEstimator(client)
 	}
-	defer provider.Close()
 
 	var tracker gasprice.Tracker
 	tracker, err = gasprice.NewSubscribedTracker(ctx, provider)
 	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
-		log.Println("fallback to the polling tracker:", err)
+		slog.Warn("fallback to the polling tracker", "err", err)
 		tracker = gasprice.NewPollingTracker(ctx, provider)
 	} else if err != nil {
-		log.Fatalln("could not create tracker:", err)
+		return fmt.Errorf("could not create tracker: %w", err)
+	}
+
+	anchor := tracker
+	switch *anchorTag {
+	case "latest":
+	case "safe", "finalized":
+		tag := rpc.SafeBlockNumber
+		if *anchorTag == "finalized" {
+			tag = rpc.FinalizedBlockNumber
+		}
+		anchor, err = gasprice.NewTaggedTracker(ctx, tracker, provider, tag)
+		if err != nil {
+			return fmt.Errorf("could not create anchor tracker: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown anchor tag: %s", *anchorTag)
+	}
+
+	names := []string{"low", "medium", "high"}
+	mux := http.NewServeMux()
+	var estimator handler.Estimator
+	switch *chain {
+	case "ethereum", "optimism":
+		baseFees := gasprice.OptimismBaseFeeConfig(*canyonTime)
+		// blob transactions are only priced on the l1
+		var blobs gasprice.BlobSchedule
+		if *chain == "ethereum" {
+			baseFees = gasprice.EthereumBaseFeeConfig
+			blobs, err = newBlobSchedule(ctx, provider, *blobSchedulePath)
+			if err != nil {
+				return err
+			}
+		}
+		estimator, err = newEstimator(ctx, provider, batcher, receipts, tracker, anchor, baseFees, blobs)
+		if err != nil {
+			return err
+		}
+		if *chain == "optimism" {
+			oracle, err := gasprice.NewOptimismOracle(caller)
+			if err != nil {
+				return fmt.Errorf("could not create optimism oracle: %w", err)
+			}
+			mux.Handle("/v1/l1fee", handler.NewL1Fee(estimator, oracle, names))
+		}
+	case "arbitrum":
+		estimator, err = gasprice.NewArbitrumEstimator(ctx, anchor, caller, len(names))
+		if err != nil {
+			return fmt.Errorf("could not create estimator: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown chain: %s", *chain)
+	}
+	feeds, err := newFeeds(caller, *fiat)
+	if err != nil {
+		return fmt.Errorf("could not create price feeds: %w", err)
+	}
+	if *blockTime == 0 {
+		*blockTime = blockTimes[*chain]
+	}
+	root := handler.New(estimator, names, feeds, *blockTime)
+	// the original flat tiers stay at the root for the older clients
+	mux.Handle("/", handler.NewLegacy(estimator, names))
+	mux.Handle("/v1/{$}", root)
+	mux.Handle("/v1/stream", handler.NewStream(root, estimator.(handler.Subscriber)))
+	if gasEstimator != nil {
+		mux.Handle("/v1/quote", handler.NewQuote(estimator, gasEstimator, names))
+	}
+	if *historyPath != "" {
+		store, err := history.NewSQLite(*historyPath)
+		if err != nil {
+			return fmt.Errorf("could not open history: %w", err)
+		}
+		defer store.Close()
+		recorded := make(chan struct{})
+		go func() {
+			history.Run(ctx, estimator.(history.Subscriber), store, *historyRetention)
+			close(recorded)
+		}()
+		// the store is closed once the last estimate is saved
+		defer func() {
+			stop()
+			<-recorded
+		}()
+		mux.Handle("/v1/history", handler.NewHistory(store, names))
+	}
+	if *webhooksPath != "" {
+		if *keysPath == "" {
+			return errors.New("webhooks need api keys to tell their owners apart")
+		}
+		registry, err := alert.NewRegistry(*webhooksPath, names)
+		if err != nil {
+			return fmt.Errorf("could not load webhooks: %w", err)
+		}
+		go registry.Run(ctx, estimator.(alert.Subscriber))
+		mux.Handle("/v1/webhooks", handler.NewWebhooks(registry))
+	}
+
+	proxies, err := parseProxies(*trustedProxies)
+	if err != nil {
+		return err
+	}
+	guard, err := auth.NewGuard(*keysPath, *ipRate, *ipBurst, proxies)
+	if err != nil {
+		return fmt.Errorf("could not load api keys: %w", err)
 	}
+	go guard.Watch(ctx, 5*time.Second)
+	if *keysPath != "" {
+		mux.Handle("/v1/usage", guard)
+	}
+
+	errs := make(chan error, 2)
+	var grpcServer *grpc.Server
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return fmt.Errorf("could not listen for grpc: %w", err)
+		}
+		grpcServer = grpc.NewServer(
+			grpc.StatsHandler(otelgrpc.NewServerHandler()),
+			grpc.ChainUnaryInterceptor(guard.UnaryInterceptor),
+			grpc.ChainStreamInterceptor(guard.StreamInterceptor),
+		)
+		yaegpev1.RegisterGasPriceServiceServer(grpcServer, grpcapi.New(estimator, names))
+		slog.Info("start grpc server", "addr", *grpcAddr)
+		go func() {
+			errs <- grpcServer.Serve(listener)
+		}()
+	}
+
+	if replayed != nil {
+		go replayed.Play(ctx, *replaySpeed)
+	}
+
+	cors := handler.NewCORS(
+		guard.Middleware(mux),
+		strings.Split(*corsOrigins, ","),
+		strings.Split(*corsMethods, ","),
+		strings.Split(*corsHeaders, ","),
+		*corsMaxAge,
+	)
+	slog.Info("start server", "addr", *addr)
+	traced := otelhttp.NewHandler(
+		logging.Middleware(cors),
+		"http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+	server := &http.Server{Addr: *addr, Handler: traced}
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+	slog.Info("shut down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	// streams do not end on their own, so they are cut at the timeout
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if grpcServer == nil {
+			return
+		}
+		go func() {
+			<-shutdownCtx.Done()
+			grpcServer.Stop()
+		}()
+		grpcServer.GracefulStop()
+	}()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("close the remaining connections", "err", err)
+		server.Close()
+	}
+	<-stopped
+	return nil
+}
+
+// providerName names the provider in the logs without the credentials that
+// provider urls often carry in their path or user info.
+func providerName(providerURL string, replayPath string) string {
+	if replayPath != "" {
+		return "replay:" + replayPath
+	}
+	u, err := url.Parse(providerURL)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
 
-	sampler, err := gasprice.NewMinimumSampler(provider, sampleSize, sampleMinPrice)
+func newEstimator(
+	ctx context.Context,
+	provider gasprice.Provider,
+	batcher gasprice.Batcher,
+	receipts gasprice.ReceiptProvider,
+	tracker gasprice.Tracker,
+	anchor gasprice.Tracker,
+	baseFees gasprice.BaseFeeConfig,
+	blobs gasprice.BlobSchedule,
+) (*gasprice.Estimator, error) {
+	sampler, err := gasprice.NewMinimumSampler(
+		provider,
+		batcher,
+		receipts,
+		sampleSize,
+		sampleMinPrice,
+	)
 	if err != nil {
-		log.Fatalln("could not create sampler:", err)
+		return nil, fmt.Errorf("could not create sampler: %w", err)
 	}
+	sampler.Follow(ctx, anchor)
 
 	estimator, err := gasprice.NewEstimator(
 		ctx,
 		tracker,
+		anchor,
 		sampler,
 		estimatorHistory,
 		estimatorSkip,
 		estimatorTarget,
+		baseFees,
+		blobs,
 	)
 	if err != nil {
-		log.Fatalln("could not create estimator:", err)
+		return nil, fmt.Errorf("could not create estimator: %w", err)
+	}
+	return estimator, nil
+}
+
+// newBlobSchedule loads the blob schedule from the path, or picks the one of
+// the chain, which is nil on unknown chains.
+func newBlobSchedule(ctx context.Context, provider gasprice.Provider, path string) (gasprice.BlobSchedule, error) {
+	if path != "" {
+		blobs, err := gasprice.LoadBlobSchedule(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not load blob schedule: %w", err)
+		}
+		return blobs, nil
+	}
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get chain id: %w", err)
+	}
+	blobs := gasprice.ChainBlobSchedule(chainID)
+	if blobs == nil {
+		slog.Warn("unknown blob schedule, leaving out blob prices", "chain_id", chainID)
 	}
+	return blobs, nil
+}
+
+func parseProxies(spec string) ([]netip.Prefix, error) {
+	proxies := make([]netip.Prefix, 0)
+	if spec == "" {
+		return proxies, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		proxy, err := netip.ParsePrefix(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, fmt.Errorf("bad trusted proxy: %s", entry)
+		}
+		proxies = append(proxies, proxy.Masked())
+	}
+	return proxies, nil
+}
+
+func newFeeds(caller ethereum.ContractCaller, spec string) (map[string]handler.PriceFeed, error) {
+	feeds := make(map[string]handler.PriceFeed)
+	if spec == "" {
+		return feeds, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		currency, source, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("bad price feed: %s", entry)
+		}
+		kind, arg, ok := strings.Cut(source, ":")
+		if !ok {
+			return nil, fmt.Errorf("bad price feed: %s", entry)
+		}
 
-	h := handler.New(estimator, []string{"low", "medium", "high"})
-	log.Println("start server on:", *addr)
-	if err := http.ListenAndServe(*addr, h); err != nil {
-		log.Fatalln("server error:", err)
+		switch kind {
+		case "chainlink":
+			if caller == nil {
+				return nil, fmt.Errorf("chainlink feeds need a provider: %s", entry)
+			}
+			address, rawHeartbeat, hasHeartbeat := strings.Cut(arg, ":")
+			if !common.IsHexAddress(address) {
+				return nil, fmt.Errorf("bad aggregator address: %s", address)
+			}
+			heartbeat := pricefeed.DefaultHeartbeat
+			if hasHeartbeat {
+				var err error
+				heartbeat, err = time.ParseDuration(rawHeartbeat)
+				if err != nil || heartbeat <= 0 {
+					return nil, fmt.Errorf("bad heartbeat: %s", entry)
+				}
+			}
+			feed, err := pricefeed.NewChainlink(caller, common.HexToAddress(address), heartbeat)
+			if err != nil {
+				return nil, err
+			}
+			feeds[currency] = feed
+		case "static":
+			feed, err := pricefeed.NewStatic(arg)
+			if err != nil {
+				return nil, err
+			}
+			feeds[currency] = feed
+		case "file":
+			feeds[currency] = pricefeed.NewFile(arg, currency)
+		default:
+			return nil, fmt.Errorf("unknown price feed: %s", kind)
+		}
 	}
+	return feeds, nil
 }
@@ -0,0 +1,92 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var ErrNoTier = errors.New("tier does not exist")
+
+// MinTipCap is the lowest tip suggested, the default minimum of geth miners,
+// so a tier priced below the next base fee still gets a tip they include.
+const MinTipCap = params.GWei / 1000
+
+type Estimator interface {
+	GasPrices(ctx context.Context) (gasprice.Estimate, error)
+}
+
+// tipCap returns the tip of the tier, at least MinTipCap.
+func tipCap(ctx context.Context, estimator Estimator, tier int) (*big.Int, error) {
+	_, fee, err := fee(ctx, estimator, tier)
+	if err != nil {
+		return nil, err
+	}
+	if fee.MaxPriorityFeePerGas.Cmp(big.NewInt(MinTipCap)) < 0 {
+		return big.NewInt(MinTipCap), nil
+	}
+	return fee.MaxPriorityFeePerGas, nil
+}
+
+func fee(ctx context.Context, estimator Estimator, tier int) (*big.Int, gasprice.Fee, error) {
+	estimate, err := estimator.GasPrices(ctx)
+	if err != nil {
+		return nil, gasprice.Fee{}, err
+	}
+	if tier < 0 || tier >= len(estimate.Prices) {
+		return nil, gasprice.Fee{}, ErrNoTier
+	}
+	return estimate.Prices[tier], estimate.Fees()[tier], nil
+}
+
+// Transactor suggests the gas price and tip of a tier of the estimator and
+// delegates everything else to the wrapped transactor, so transactions built
+// by bind.BoundContract are priced by yaegpe.
+type Transactor struct {
+	bind.ContractTransactor
+	estimator Estimator
+	tier      int
+}
+
+var _ bind.ContractTransactor = (*Transactor)(nil)
+
+func NewTransactor(transactor bind.ContractTransactor, estimator Estimator, tier int) *Transactor {
+	return &Transactor{transactor, estimator, tier}
+}
+
+func (t *Transactor) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	price, _, err := fee(ctx, t.estimator, t.tier)
+	return price, err
+}
+
+// SuggestGasTipCap returns what the tier pays above the next base fee, and
+// MinTipCap when that is less.
+func (t *Transactor) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return tipCap(ctx, t.estimator, t.tier)
+}
+
+// Backend is Transactor for the backends abigen bindings are created with.
+type Backend struct {
+	bind.ContractBackend
+	estimator Estimator
+	tier      int
+}
+
+var _ bind.ContractBackend = (*Backend)(nil)
+
+func NewBackend(backend bind.ContractBackend, estimator Estimator, tier int) *Backend {
+	return &Backend{backend, estimator, tier}
+}
+
+func (b *Backend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	price, _, err := fee(ctx, b.estimator, b.tier)
+	return price, err
+}
+
+func (b *Backend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return tipCap(ctx, b.estimator, b.tier)
+}
@@ -0,0 +1,94 @@
+package oracle
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type estimatorMock struct{}
+
+func (e estimatorMock) GasPrices(ctx context.Context) (gasprice.Estimate, error) {
+	return gasprice.Estimate{
+		Prices:      []*big.Int{big.NewInt(90 * params.GWei), big.NewInt(130 * params.GWei)},
+		NextBaseFee: big.NewInt(100 * params.GWei),
+	}, nil
+}
+
+type transactorMock struct {
+	bind.ContractTransactor
+	sent *types.Transaction
+}
+
+func (t *transactorMock) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(100 * params.GWei)}, nil
+}
+
+func (t *transactorMock) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (t *transactorMock) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (t *transactorMock) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 21000, nil
+}
+
+func (t *transactorMock) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	t.sent = tx
+	return nil
+}
+
+func TestTransactor(t *testing.T) {
+	ctx := context.Background()
+	transactor := NewTransactor(&transactorMock{}, estimatorMock{}, 1)
+	price, err := transactor.SuggestGasPrice(ctx)
+	if err != nil || price.Int64() != 130*params.GWei {
+		t.Fatalf("SuggestGasPrice returned %d, %v", price, err)
+	}
+	tip, err := transactor.SuggestGasTipCap(ctx)
+	if err != nil || tip.Int64() != 30*params.GWei {
+		t.Fatalf("SuggestGasTipCap returned %d, %v", tip, err)
+	}
+
+	// a tier below the next base fee still pays the minimum tip
+	tip, err = NewTransactor(&transactorMock{}, estimatorMock{}, 0).SuggestGasTipCap(ctx)
+	if err != nil || tip.Int64() != MinTipCap {
+		t.Fatalf("SuggestGasTipCap returned %d, %v", tip, err)
+	}
+	tip, err = NewBackend(nil, estimatorMock{}, 0).SuggestGasTipCap(ctx)
+	if err != nil || tip.Int64() != MinTipCap {
+		t.Fatalf("SuggestGasTipCap returned %d, %v", tip, err)
+	}
+
+	if _, err := NewTransactor(&transactorMock{}, estimatorMock{}, 2).SuggestGasPrice(ctx); err != ErrNoTier {
+		t.Fatal("SuggestGasPrice accepted an unknown tier")
+	}
+}
+
+func TestTransactorBoundContract(t *testing.T) {
+	mock := &transactorMock{}
+	contract := bind.NewBoundContract(common.Address{1}, abi.ABI{}, nil, NewTransactor(mock, estimatorMock{}, 0), nil)
+	opts := &bind.TransactOpts{
+		Context: context.Background(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return tx, nil
+		},
+	}
+	if _, err := contract.RawTransact(opts, nil); err != nil {
+		t.Fatal("RawTransact returned error:", err)
+	}
+	if mock.sent.GasTipCap().Int64() != MinTipCap || mock.sent.GasFeeCap().Int64() != 200*params.GWei+MinTipCap {
+		t.Errorf("bound contract used caps %d and %d", mock.sent.GasTipCap(), mock.sent.GasFeeCap())
+	}
+}
@@ -0,0 +1,155 @@
+package pricefeed
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const cacheDuration = 30 * time.Second
+
+const aggregatorABI = `[
+	{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"type":"uint8"}]},
+	{"name":"latestRoundData","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}]}
+]`
+
+type Caller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// DefaultHeartbeat is the heartbeat of the ETH/USD aggregator on mainnet.
+const DefaultHeartbeat = time.Hour
+
+// Chainlink reads the price from a Chainlink aggregator through the provider,
+// rejecting answers not updated within the heartbeat of the feed. Prices are
+// cached for a short while and concurrent requests share a single refresh to
+// keep the load on the node low.
+type Chainlink struct {
+	caller    Caller
+	address   common.Address
+	abi       abi.ABI
+	heartbeat time.Duration
+	decimals  *big.Int
+	lastPrice *big.Rat
+	lastFetch time.Time
+	chans     []chan priceResult
+	lock      sync.Mutex
+}
+
+type priceResult struct {
+	price *big.Rat
+	err   error
+}
+
+func NewChainlink(caller Caller, address common.Address, heartbeat time.Duration) (*Chainlink, error) {
+	parsed, err := abi.JSON(strings.NewReader(aggregatorABI))
+	if err != nil {
+		return nil, err
+	}
+	return &Chainlink{
+		caller,
+		address,
+		parsed,
+		heartbeat,
+		nil,
+		nil,
+		time.Time{},
+		nil,
+		sync.Mutex{},
+	}, nil
+}
+
+func (c *Chainlink) call(ctx context.Context, method string) ([]interface{}, error) {
+	data, err := c.abi.Pack(method)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.caller.CallContract(ctx, ethereum.CallMsg{To: &c.address, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.abi.Unpack(method, output)
+}
+
+// fetch reads the price from the aggregator without holding the lock, so a
+// slow provider does not block the requests the cache can answer.
+func (c *Chainlink) fetch(ctx context.Context, decimals *big.Int) (*big.Rat, *big.Int, error) {
+	if decimals == nil {
+		values, err := c.call(ctx, "decimals")
+		if err != nil {
+			return nil, nil, err
+		}
+		decimals = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(values[0].(uint8))), nil)
+	}
+
+	values, err := c.call(ctx, "latestRoundData")
+	if err != nil {
+		return nil, nil, err
+	}
+	answer := values[1].(*big.Int)
+	if answer.Sign() <= 0 {
+		return nil, nil, ErrBadPrice
+	}
+	updatedAt := values[3].(*big.Int)
+	if !updatedAt.IsInt64() || time.Since(time.Unix(updatedAt.Int64(), 0)) > c.heartbeat {
+		return nil, nil, ErrStalePrice
+	}
+	return new(big.Rat).SetFrac(answer, decimals), decimals, nil
+}
+
+// broadcastPrice refreshes the price for everyone waiting on it, with a
+// context of its own so a caller giving up does not fail the others.
+func (c *Chainlink) broadcastPrice(decimals *big.Int) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	price, decimals, err := c.fetch(ctx, decimals)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err == nil {
+		c.decimals = decimals
+		c.lastPrice = price
+		c.lastFetch = time.Now()
+	}
+	for _, ch := range c.chans {
+		if err != nil {
+			ch <- priceResult{nil, err}
+		} else {
+			ch <- priceResult{new(big.Rat).Set(price), nil}
+		}
+		close(ch)
+	}
+	c.chans = nil
+}
+
+func (c *Chainlink) asyncPrice() <-chan priceResult {
+	ch := make(chan priceResult, 1)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.lastPrice != nil && time.Since(c.lastFetch) < cacheDuration {
+		ch <- priceResult{new(big.Rat).Set(c.lastPrice), nil}
+		close(ch)
+		return ch
+	}
+
+	c.chans = append(c.chans, ch)
+	if len(c.chans) == 1 {
+		go c.broadcastPrice(c.decimals)
+	}
+	return ch
+}
+
+func (c *Chainlink) Price(ctx context.Context) (*big.Rat, error) {
+	select {
+	case r := <-c.asyncPrice():
+		return r.price, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
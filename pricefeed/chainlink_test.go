@@ -0,0 +1,138 @@
+package pricefeed
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type callerMock struct {
+	abi       abi.ABI
+	answer    *big.Int
+	updatedAt time.Time
+	calls     int32
+	wait      chan struct{}
+}
+
+func (c *callerMock) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.wait != nil {
+		<-c.wait
+	}
+	if bytes.HasPrefix(msg.Data, c.abi.Methods["decimals"].ID) {
+		return c.abi.Methods["decimals"].Outputs.Pack(uint8(8))
+	}
+	return c.abi.Methods["latestRoundData"].Outputs.Pack(
+		big.NewInt(1), c.answer, big.NewInt(0), big.NewInt(c.updatedAt.Unix()), big.NewInt(1),
+	)
+}
+
+func TestChainlink(t *testing.T) {
+	feed, err := NewChainlink(nil, common.Address{}, time.Hour)
+	if err != nil {
+		t.Fatal("could not create feed:", err)
+	}
+	caller := &callerMock{abi: feed.abi, answer: big.NewInt(300012345678), updatedAt: time.Now()}
+	feed.caller = caller
+
+	ctx := context.Background()
+	price, err := feed.Price(ctx)
+	if err != nil {
+		t.Fatal("Price returned error:", err)
+	}
+	if price.FloatString(8) != "3000.12345678" {
+		t.Errorf("Price returned %s", price.FloatString(8))
+	}
+
+	caller.calls = 0
+	if _, err := feed.Price(ctx); err != nil {
+		t.Fatal("Price returned error when reading from cache:", err)
+	}
+	if caller.calls != 0 {
+		t.Error("Price should be returned from cache")
+	}
+
+	feed.lastPrice = nil
+	caller.answer = big.NewInt(0)
+	if _, err := feed.Price(ctx); err != ErrBadPrice {
+		t.Error("Price should fail on a non-positive answer")
+	}
+
+	caller.answer = big.NewInt(300012345678)
+	caller.updatedAt = time.Now().Add(-2 * time.Hour)
+	if _, err := feed.Price(ctx); err != ErrStalePrice {
+		t.Error("Price should fail on an answer older than the heartbeat")
+	}
+}
+
+func TestChainlinkFetchUnlocked(t *testing.T) {
+	feed, err := NewChainlink(nil, common.Address{}, time.Hour)
+	if err != nil {
+		t.Fatal("could not create feed:", err)
+	}
+	caller := &callerMock{abi: feed.abi, answer: big.NewInt(300012345678), updatedAt: time.Now(), wait: make(chan struct{})}
+	feed.caller = caller
+
+	done := make(chan error)
+	go func() {
+		_, err := feed.Price(context.Background())
+		done <- err
+	}()
+	for atomic.LoadInt32(&caller.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if !feed.lock.TryLock() {
+		t.Fatal("Price holds the lock while calling the aggregator")
+	}
+	feed.lock.Unlock()
+
+	close(caller.wait)
+	if err := <-done; err != nil {
+		t.Fatal("Price returned error:", err)
+	}
+}
+
+func TestChainlinkSingleFlight(t *testing.T) {
+	feed, err := NewChainlink(nil, common.Address{}, time.Hour)
+	if err != nil {
+		t.Fatal("could not create feed:", err)
+	}
+	caller := &callerMock{abi: feed.abi, answer: big.NewInt(300012345678), updatedAt: time.Now(), wait: make(chan struct{})}
+	feed.caller = caller
+
+	const n = 10
+	done := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := feed.Price(context.Background())
+			done <- err
+		}()
+	}
+	for {
+		feed.lock.Lock()
+		waiting := len(feed.chans)
+		feed.lock.Unlock()
+		if waiting == n {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(caller.wait)
+	for i := 0; i < n; i++ {
+		if err := <-done; err != nil {
+			t.Fatal("Price returned error:", err)
+		}
+	}
+	// a single decimals and latestRoundData pair
+	if calls := atomic.LoadInt32(&caller.calls); calls != 2 {
+		t.Errorf("Price called the aggregator %d times but expected 2", calls)
+	}
+}
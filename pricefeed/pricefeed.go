@@ -0,0 +1,65 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+)
+
+var ErrBadPrice = errors.New("price is invalid")
+var ErrNoPrice = errors.New("no price for the currency")
+var ErrStalePrice = errors.New("price is older than the heartbeat")
+
+// Feed reports the price of one ether in a fiat currency.
+type Feed interface {
+	Price(ctx context.Context) (*big.Rat, error)
+}
+
+type Static struct {
+	price *big.Rat
+}
+
+func NewStatic(price string) (*Static, error) {
+	p, ok := new(big.Rat).SetString(price)
+	if !ok || p.Sign() <= 0 {
+		return nil, ErrBadPrice
+	}
+	return &Static{p}, nil
+}
+
+func (s *Static) Price(ctx context.Context) (*big.Rat, error) {
+	return new(big.Rat).Set(s.price), nil
+}
+
+// File reads the price from a JSON file mapping currencies to prices, such as
+// {"USD": "3000.50"}, on every call so the price can be changed while running.
+type File struct {
+	path     string
+	currency string
+}
+
+func NewFile(path string, currency string) *File {
+	return &File{path, currency}
+}
+
+func (f *File) Price(ctx context.Context) (*big.Rat, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[string]string)
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, err
+	}
+	price, ok := prices[f.currency]
+	if !ok {
+		return nil, ErrNoPrice
+	}
+	p, ok := new(big.Rat).SetString(price)
+	if !ok || p.Sign() <= 0 {
+		return nil, ErrBadPrice
+	}
+	return p, nil
+}
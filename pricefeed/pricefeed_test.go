@@ -0,0 +1,65 @@
+package pricefeed
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestStatic(t *testing.T) {
+	tests := []struct {
+		price  string
+		expect string
+	}{
+		{"3000.5", "3000.50"},
+		{"0", ""},
+		{"abc", ""},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.FormatInt(int64(i), 10), func(t *testing.T) {
+			feed, err := NewStatic(test.price)
+			if test.expect == "" {
+				if err == nil {
+					t.Error("NewStatic accepted an invalid price")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("NewStatic returned error:", err)
+			}
+			price, err := feed.Price(context.Background())
+			if err != nil {
+				t.Fatal("Price returned error:", err)
+			}
+			if price.FloatString(2) != test.expect {
+				t.Errorf("Price returned %s but expected %s", price.FloatString(2), test.expect)
+			}
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"USD": "3000.25", "EUR": "-1"}`), 0644); err != nil {
+		t.Fatal("could not write prices:", err)
+	}
+	ctx := context.Background()
+
+	price, err := NewFile(path, "USD").Price(ctx)
+	if err != nil {
+		t.Fatal("Price returned error:", err)
+	}
+	if price.Cmp(big.NewRat(300025, 100)) != 0 {
+		t.Errorf("Price returned %s", price.FloatString(2))
+	}
+	if _, err := NewFile(path, "EUR").Price(ctx); err != ErrBadPrice {
+		t.Error("Price should fail on a negative price")
+	}
+	if _, err := NewFile(path, "GBP").Price(ctx); err != ErrNoPrice {
+		t.Error("Price should fail on a missing currency")
+	}
+}
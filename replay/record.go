@@ -0,0 +1,144 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	kindChainID = "chainId"
+	kindHeader  = "header"
+	kindBlock   = "block"
+	kindNewHead = "newHead"
+)
+
+// entry is one line of a recording. At is the number of milliseconds since
+// the recording started and Number is the requested block number of a
+// header, empty for the latest one.
+type entry struct {
+	Kind    string        `json:"kind"`
+	At      int64         `json:"at"`
+	Number  string        `json:"number,omitempty"`
+	ChainID *hexutil.Big  `json:"chainId,omitempty"`
+	Header  *types.Header `json:"header,omitempty"`
+	Block   hexutil.Bytes `json:"block,omitempty"`
+}
+
+func numberKey(number *big.Int) string {
+	if number == nil {
+		return ""
+	}
+	return number.String()
+}
+
+// Recorder is a provider writing every response of the wrapped provider to a
+// file as JSON lines, to be served back by Replay.
+type Recorder struct {
+	provider gasprice.Provider
+	file     *os.File
+	encoder  *json.Encoder
+	start    time.Time
+	lock     sync.Mutex
+}
+
+func NewRecorder(provider gasprice.Provider, path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{provider, file, json.NewEncoder(file), time.Now(), sync.Mutex{}}, nil
+}
+
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.file.Close()
+}
+
+func (r *Recorder) record(e entry) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	e.At = time.Since(r.start).Milliseconds()
+	return r.encoder.Encode(e)
+}
+
+func (r *Recorder) recordBlock(block *types.Block) error {
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return err
+	}
+	return r.record(entry{Kind: kindBlock, Block: data})
+}
+
+func (r *Recorder) ChainID(ctx context.Context) (*big.Int, error) {
+	chainID, err := r.provider.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return chainID, r.record(entry{Kind: kindChainID, ChainID: (*hexutil.Big)(chainID)})
+}
+
+func (r *Recorder) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := r.provider.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return header, r.record(entry{Kind: kindHeader, Number: numberKey(number), Header: header})
+}
+
+func (r *Recorder) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	block, err := r.provider.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return block, r.recordBlock(block)
+}
+
+func (r *Recorder) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	block, err := r.provider.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block, r.recordBlock(block)
+}
+
+// SubscribeNewHead records the new heads before passing them on.
+func (r *Recorder) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	heads := make(chan *types.Header)
+	sub, err := r.provider.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case header := <-heads:
+				if err := r.record(entry{Kind: kindNewHead, Header: header}); err != nil {
+					return err
+				}
+				select {
+				case ch <- header:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
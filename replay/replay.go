@@ -0,0 +1,196 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var ErrNotRecorded = errors.New("chain id was not recorded")
+
+// Replay is a provider serving a recording of Recorder. The new heads are
+// sent to the subscribers in the recorded order, either one by one on Advance
+// or at the recorded pace by Play. The latest header is the last head sent, and tagged headers are the ones
+// recorded by the time of that head.
+type Replay struct {
+	chainID  *big.Int
+	tagged   map[string][]entry
+	byNumber map[uint64]*types.Header
+	blocks   map[common.Hash]*types.Block
+	heads    []entry
+	feed     event.Feed
+	next     int
+	current  *types.Header
+	clock    int64
+	lock     sync.RWMutex
+}
+
+func NewReplay(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := &Replay{
+		nil,
+		make(map[string][]entry),
+		make(map[uint64]*types.Header),
+		make(map[common.Hash]*types.Block),
+		make([]entry, 0),
+		event.Feed{},
+		0,
+		nil,
+		0,
+		sync.RWMutex{},
+	}
+	decoder := json.NewDecoder(file)
+	for {
+		var e entry
+		err := decoder.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := r.load(e); err != nil {
+			return nil, err
+		}
+	}
+	if latest := r.tagged[""]; len(latest) > 0 {
+		r.current = latest[0].Header
+	}
+	return r, nil
+}
+
+func (r *Replay) load(e entry) error {
+	switch e.Kind {
+	case kindChainID:
+		r.chainID = e.ChainID.ToInt()
+	case kindHeader:
+		r.byNumber[e.Header.Number.Uint64()] = e.Header
+		r.tagged[e.Number] = append(r.tagged[e.Number], e)
+	case kindBlock:
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(e.Block, block); err != nil {
+			return err
+		}
+		r.blocks[block.Hash()] = block
+		r.byNumber[block.NumberU64()] = block.Header()
+	case kindNewHead:
+		r.byNumber[e.Header.Number.Uint64()] = e.Header
+		r.heads = append(r.heads, e)
+	}
+	return nil
+}
+
+// Play sends the remaining heads at the recorded pace scaled by the speed,
+// relative to the first of them, until all are sent or the context is done.
+func (r *Replay) Play(ctx context.Context, speed float64) {
+	r.lock.RLock()
+	next := r.next
+	r.lock.RUnlock()
+	if next == len(r.heads) {
+		return
+	}
+
+	start := time.Now()
+	for i := next; i < len(r.heads); i++ {
+		offset := float64(r.heads[i].At-r.heads[next].At) / speed
+		at := start.Add(time.Duration(offset * float64(time.Millisecond)))
+		select {
+		case <-time.After(time.Until(at)):
+			r.Advance()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Advance sends the next recorded head to the subscribers, waiting for them
+// to receive it. It returns false if every head was already sent.
+func (r *Replay) Advance() bool {
+	r.lock.Lock()
+	if r.next == len(r.heads) {
+		r.lock.Unlock()
+		return false
+	}
+	head := r.heads[r.next]
+	r.next++
+	r.current = head.Header
+	r.clock = head.At
+	r.lock.Unlock()
+
+	r.feed.Send(head.Header)
+	return true
+}
+
+func (r *Replay) ChainID(ctx context.Context) (*big.Int, error) {
+	if r.chainID == nil {
+		return nil, ErrNotRecorded
+	}
+	return new(big.Int).Set(r.chainID), nil
+}
+
+func (r *Replay) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if number == nil {
+		if r.current == nil {
+			return nil, ethereum.NotFound
+		}
+		return types.CopyHeader(r.current), nil
+	}
+	if number.Sign() >= 0 {
+		header, ok := r.byNumber[number.Uint64()]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		return types.CopyHeader(header), nil
+	}
+
+	entries := r.tagged[numberKey(number)]
+	if len(entries) == 0 {
+		return nil, ethereum.NotFound
+	}
+	header := entries[0].Header
+	for _, e := range entries {
+		if e.At > r.clock {
+			break
+		}
+		header = e.Header
+	}
+	return types.CopyHeader(header), nil
+}
+
+func (r *Replay) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	block, ok := r.blocks[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return block, nil
+}
+
+func (r *Replay) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	header, err := r.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return r.BlockByHash(ctx, header.Hash())
+}
+
+func (r *Replay) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return r.feed.Subscribe(ch), nil
+}
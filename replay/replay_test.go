@@ -0,0 +1,211 @@
+package replay
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var chainID = big.NewInt(1337)
+
+type providerMock struct {
+	blocks  []*types.Block
+	current int
+	feed    event.Feed
+	lock    sync.RWMutex
+}
+
+func newProviderMock(t *testing.T, count int) *providerMock {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal("could not generate key:", err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+
+	p := &providerMock{}
+	parent := common.Hash{}
+	for i := 0; i < count; i++ {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(int64(i)),
+			GasLimit:   30_000_000,
+			GasUsed:    15_000_000,
+			BaseFee:    big.NewInt(1_000_000_000),
+			Time:       uint64(12 * i),
+		}
+		txs := make([]*types.Transaction, 0)
+		for j := 0; j < 4; j++ {
+			tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+				ChainID:   chainID,
+				Nonce:     uint64(i*4 + j),
+				GasTipCap: big.NewInt(int64((i+1)*(j+1)) * 100_000_000),
+				GasFeeCap: big.NewInt(10_000_000_000),
+				Gas:       21000,
+			})
+			if err != nil {
+				t.Fatal("could not sign transaction:", err)
+			}
+			txs = append(txs, tx)
+		}
+		block := types.NewBlock(header, &types.Body{Transactions: txs}, nil, trie.NewStackTrie(nil))
+		p.blocks = append(p.blocks, block)
+		parent = block.Hash()
+	}
+	return p
+}
+
+func (p *providerMock) push() {
+	p.lock.Lock()
+	p.current++
+	header := p.blocks[p.current].Header()
+	p.lock.Unlock()
+	p.feed.Send(header)
+}
+
+func (p *providerMock) ChainID(ctx context.Context) (*big.Int, error) {
+	return chainID, nil
+}
+
+func (p *providerMock) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	block, err := p.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (p *providerMock) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range p.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (p *providerMock) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if number == nil {
+		return p.blocks[p.current], nil
+	}
+	if number.Int64() > int64(p.current) {
+		return nil, ethereum.NotFound
+	}
+	return p.blocks[number.Int64()], nil
+}
+
+func (p *providerMock) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return p.feed.Subscribe(ch), nil
+}
+
+// estimate runs the whole pipeline on the provider and returns the estimate
+// of the block with the number once advance made it the head.
+func estimate(t *testing.T, provider gasprice.Provider, advance func(), number uint64) gasprice.Estimate {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker, err := gasprice.NewSubscribedTracker(ctx, provider)
+	if err != nil {
+		t.Fatal("could not create tracker:", err)
+	}
+	sampler, err := gasprice.NewMinimumSampler(provider, nil, nil, 3, big.NewInt(1))
+	if err != nil {
+		t.Fatal("could not create sampler:", err)
+	}
+	sampler.Follow(ctx, tracker)
+	estimator, err := gasprice.NewEstimator(ctx, tracker, tracker, sampler, 0, 2, []gasprice.Target{{Start: 0, End: 0.5}, {Start: 0.5, End: 1}}, gasprice.EthereumBaseFeeConfig, nil)
+	if err != nil {
+		t.Fatal("could not create estimator:", err)
+	}
+
+	advance()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		estimate, err := estimator.GasPrices(ctx)
+		if err == nil && estimate.Block.Number == number {
+			return estimate
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("estimator did not reach block", number)
+	return gasprice.Estimate{}
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	provider := newProviderMock(t, 3)
+	provider.current = 1
+	recorder, err := NewRecorder(provider, path)
+	if err != nil {
+		t.Fatal("could not create recorder:", err)
+	}
+	recorded := estimate(t, recorder, provider.push, 2)
+	if err := recorder.Close(); err != nil {
+		t.Fatal("could not close recorder:", err)
+	}
+
+	replay, err := NewReplay(path)
+	if err != nil {
+		t.Fatal("could not load recording:", err)
+	}
+	replayed := estimate(t, replay, func() { replay.Advance() }, 2)
+
+	if recorded.Block != replayed.Block {
+		t.Fatal("replayed estimate is for a different block")
+	}
+	if !reflect.DeepEqual(recorded.Prices, replayed.Prices) {
+		t.Fatalf("replayed prices %v differ from recorded %v", replayed.Prices, recorded.Prices)
+	}
+	if replay.Advance() {
+		t.Fatal("Advance sent more heads than recorded")
+	}
+}
+
+func TestReplayTiming(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	provider := newProviderMock(t, 3)
+	recorder, err := NewRecorder(provider, path)
+	if err != nil {
+		t.Fatal("could not create recorder:", err)
+	}
+	ch := make(chan *types.Header)
+	sub, _ := recorder.SubscribeNewHead(context.Background(), ch)
+	go provider.push()
+	<-ch
+	time.Sleep(200 * time.Millisecond)
+	go provider.push()
+	<-ch
+	sub.Unsubscribe()
+	recorder.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	replay, err := NewReplay(path)
+	if err != nil {
+		t.Fatal("could not load recording:", err)
+	}
+	heads := make(chan *types.Header)
+	replay.SubscribeNewHead(ctx, heads)
+	go replay.Play(ctx, 2)
+	first := <-heads
+	start := time.Now()
+	second := <-heads
+	elapsed := time.Since(start)
+	if first.Number.Uint64() != 1 || second.Number.Uint64() != 2 {
+		t.Fatal("replay sent the heads out of order")
+	}
+	if elapsed < 50*time.Millisecond || elapsed > 180*time.Millisecond {
+		t.Fatalf("replay sent the second head after %s but expected about 100ms", elapsed)
+	}
+}
@@ -0,0 +1,145 @@
+package simulation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"math/rand"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+const blockGasLimit = 30_000_000
+const transferGas = 21000
+
+var balance = new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18))
+
+// Distribution draws the priority fee of a transaction.
+type Distribution func(rng *rand.Rand) *big.Int
+
+func Fixed(tip *big.Int) Distribution {
+	return func(rng *rand.Rand) *big.Int {
+		return new(big.Int).Set(tip)
+	}
+}
+
+// Uniform draws priority fees uniformly between min and max, inclusive.
+func Uniform(min *big.Int, max *big.Int) Distribution {
+	return func(rng *rand.Rand) *big.Int {
+		span := new(big.Int).Sub(max, min)
+		span.Add(span, big.NewInt(1))
+		return span.Add(min, new(big.Int).Rand(rng, span))
+	}
+}
+
+// Chain is an in-process chain built on the simulated backend of
+// go-ethereum, where every block is mined on demand with EIP-1559 transfers.
+type Chain struct {
+	backend *simulated.Backend
+	client  simulated.Client
+	keys    []*ecdsa.PrivateKey
+	signer  types.Signer
+	rng     *rand.Rand
+}
+
+// NewChain funds the given number of accounts sending the transactions. The
+// seed makes the drawn fees deterministic.
+func NewChain(accounts int, seed int64) (*Chain, error) {
+	alloc := make(types.GenesisAlloc)
+	keys := make([]*ecdsa.PrivateKey, accounts)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.Account{Balance: balance}
+	}
+
+	backend := simulated.NewBackend(alloc, simulated.WithBlockGasLimit(blockGasLimit))
+	client := backend.Client()
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+	return &Chain{backend, client, keys, types.LatestSignerForChainID(chainID), rand.New(rand.NewSource(seed))}, nil
+}
+
+func (c *Chain) Close() error {
+	return c.backend.Close()
+}
+
+// Provider returns the provider of the node. The simulated client hides its
+// rpc client, so blocks can not be fetched in batches.
+func (c *Chain) Provider() gasprice.Provider {
+	return c.client.(gasprice.Provider)
+}
+
+func (c *Chain) Receipts() gasprice.ReceiptProvider {
+	return c.client.(gasprice.ReceiptProvider)
+}
+
+// Mine sends the transfers with priority fees drawn from the distribution,
+// spread over the accounts, and mines them in a new block. The fee caps
+// leave room for twice the current base fee.
+func (c *Chain) Mine(ctx context.Context, transfers int, distribution Distribution) (*types.Block, error) {
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < transfers; i++ {
+		key := c.keys[i%len(c.keys)]
+		from := crypto.PubkeyToAddress(key.PublicKey)
+		nonce, err := c.client.PendingNonceAt(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		tip := distribution(c.rng)
+		feeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+		to := common.Address{1}
+		tx, err := types.SignNewTx(key, c.signer, &types.DynamicFeeTx{
+			ChainID:   c.signer.ChainID(),
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: feeCap.Add(feeCap, tip),
+			Gas:       transferGas,
+			To:        &to,
+			Value:     big.NewInt(1),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := c.client.SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	return c.client.BlockByHash(ctx, c.backend.Commit())
+}
+
+// Reorg replaces the last depth blocks with a branch one block longer, so it
+// becomes canonical, mined like Mine.
+func (c *Chain) Reorg(ctx context.Context, depth int, transfers int, distribution Distribution) (*types.Block, error) {
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	ancestor, err := c.client.HeaderByNumber(ctx, new(big.Int).Sub(head.Number, big.NewInt(int64(depth))))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.backend.Fork(ancestor.Hash()); err != nil {
+		return nil, err
+	}
+	var block *types.Block
+	for i := 0; i <= depth; i++ {
+		if block, err = c.Mine(ctx, transfers, distribution); err != nil {
+			return nil, err
+		}
+	}
+	return block, nil
+}
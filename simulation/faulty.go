@@ -0,0 +1,124 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+var ErrDropped = errors.New("subscription dropped")
+
+// FaultyProvider wraps a provider to slow down its responses and drop its
+// subscriptions on demand.
+type FaultyProvider struct {
+	provider gasprice.Provider
+	delay    time.Duration
+	drops    map[chan error]struct{}
+	lock     sync.RWMutex
+}
+
+func NewFaultyProvider(provider gasprice.Provider) *FaultyProvider {
+	return &FaultyProvider{provider, 0, make(map[chan error]struct{}), sync.RWMutex{}}
+}
+
+// SetDelay delays every following response by the duration.
+func (p *FaultyProvider) SetDelay(delay time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.delay = delay
+}
+
+// DropSubscriptions ends the current subscriptions with ErrDropped.
+func (p *FaultyProvider) DropSubscriptions() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for drop := range p.drops {
+		drop <- ErrDropped
+	}
+	p.drops = make(map[chan error]struct{})
+}
+
+func (p *FaultyProvider) wait(ctx context.Context) error {
+	p.lock.RLock()
+	delay := p.delay
+	p.lock.RUnlock()
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *FaultyProvider) ChainID(ctx context.Context) (*big.Int, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.provider.ChainID(ctx)
+}
+
+func (p *FaultyProvider) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.provider.HeaderByNumber(ctx, number)
+}
+
+func (p *FaultyProvider) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.provider.BlockByHash(ctx, hash)
+}
+
+func (p *FaultyProvider) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.provider.BlockByNumber(ctx, number)
+}
+
+func (p *FaultyProvider) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	heads := make(chan *types.Header)
+	sub, err := p.provider.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+	drop := make(chan error, 1)
+	p.lock.Lock()
+	p.drops[drop] = struct{}{}
+	p.lock.Unlock()
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		defer func() {
+			p.lock.Lock()
+			delete(p.drops, drop)
+			p.lock.Unlock()
+		}()
+		for {
+			select {
+			case header := <-heads:
+				select {
+				case ch <- header:
+				case <-quit:
+					return nil
+				}
+			case err := <-drop:
+				return err
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
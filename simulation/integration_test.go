@@ -0,0 +1,169 @@
+package simulation
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ArmanMazdaee/yaegpe/client"
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ArmanMazdaee/yaegpe/handler"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const gwei = 1_000_000_000
+
+var names = []string{"low", "medium", "high"}
+var targets = []gasprice.Target{{Start: 0, End: 0.3}, {Start: 0.3, End: 0.6}, {Start: 0.6, End: 1}}
+
+type pipeline struct {
+	chain    *Chain
+	provider *FaultyProvider
+	url      string
+}
+
+// newPipeline mines the blocks the first estimate is sampled from and serves
+// the estimates of the chain through a faulty provider over HTTP.
+func newPipeline(t *testing.T, useReceipts bool) *pipeline {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	chain, err := NewChain(4, 1)
+	if err != nil {
+		t.Fatal("could not create chain:", err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	for i := 0; i < 3; i++ {
+		if _, err := chain.Mine(ctx, 10, Fixed(big.NewInt(2*gwei))); err != nil {
+			t.Fatal("could not mine:", err)
+		}
+	}
+
+	provider := NewFaultyProvider(chain.Provider())
+	tracker, err := gasprice.NewSubscribedTracker(ctx, provider)
+	if err != nil {
+		t.Fatal("could not create tracker:", err)
+	}
+	var receipts gasprice.ReceiptProvider
+	if useReceipts {
+		receipts = chain.Receipts()
+	}
+	sampler, err := gasprice.NewMinimumSampler(provider, nil, receipts, 7, big.NewInt(1))
+	if err != nil {
+		t.Fatal("could not create sampler:", err)
+	}
+	sampler.Follow(ctx, tracker)
+	estimator, err := gasprice.NewEstimator(ctx, tracker, tracker, sampler, 0, 3, targets, gasprice.EthereumBaseFeeConfig, nil)
+	if err != nil {
+		t.Fatal("could not create estimator:", err)
+	}
+
+	server := httptest.NewServer(handler.New(estimator, names, nil, 0))
+	t.Cleanup(server.Close)
+	return &pipeline{chain, provider, server.URL}
+}
+
+// waitFor polls the API until it reports an estimate for the block.
+func (p *pipeline) waitFor(t *testing.T, block *types.Block) *client.GasPrices {
+	c := client.New(p.url, 5*time.Second, 0, 0)
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		prices, err := c.GasPrices(context.Background())
+		if err == nil && prices.Block.Hash == block.Hash() {
+			return prices
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("no estimate for block", block.Number())
+	return nil
+}
+
+func checkTiers(t *testing.T, prices *client.GasPrices, min int64, max int64) {
+	for i, name := range names {
+		price, ok := prices.Prices[name]
+		if !ok {
+			t.Fatalf("estimate has no %s tier", name)
+		}
+		if price.Cmp(big.NewInt(min)) < 0 || price.Cmp(big.NewInt(max)) > 0 {
+			t.Errorf("%s tier %d is out of [%d, %d]", name, price, min, max)
+		}
+		if i > 0 && price.Cmp(prices.Prices[names[i-1]]) < 0 {
+			t.Errorf("%s tier is below the %s tier", name, names[i-1])
+		}
+	}
+	if prices.NextBaseFee == nil {
+		t.Error("estimate has no next base fee")
+	}
+}
+
+func TestPipelineEstimates(t *testing.T) {
+	for _, useReceipts := range []bool{false, true} {
+		p := newPipeline(t, useReceipts)
+		block, err := p.chain.Mine(context.Background(), 20, Uniform(big.NewInt(1*gwei), big.NewInt(6*gwei)))
+		if err != nil {
+			t.Fatal("could not mine:", err)
+		}
+
+		prices := p.waitFor(t, block)
+		checkTiers(t, prices, 1*gwei, 8*gwei)
+		if prices.Prices["low"].Cmp(prices.Prices["high"]) >= 0 {
+			t.Error("tiers do not follow the spread of the fees")
+		}
+	}
+}
+
+func TestPipelineReorg(t *testing.T) {
+	p := newPipeline(t, false)
+	ctx := context.Background()
+	block, err := p.chain.Mine(ctx, 10, Fixed(big.NewInt(2*gwei)))
+	if err != nil {
+		t.Fatal("could not mine:", err)
+	}
+	p.waitFor(t, block)
+
+	reorged, err := p.chain.Reorg(ctx, 2, 10, Fixed(big.NewInt(5*gwei)))
+	if err != nil {
+		t.Fatal("could not reorg:", err)
+	}
+	prices := p.waitFor(t, reorged)
+	// the sample only holds blocks of the new branch
+	checkTiers(t, prices, 5*gwei, 8*gwei)
+}
+
+func TestPipelineSubscriptionDrop(t *testing.T) {
+	p := newPipeline(t, false)
+	p.provider.DropSubscriptions()
+	// let the tracker subscribe again
+	time.Sleep(100 * time.Millisecond)
+
+	block, err := p.chain.Mine(context.Background(), 10, Fixed(big.NewInt(3*gwei)))
+	if err != nil {
+		t.Fatal("could not mine:", err)
+	}
+	prices := p.waitFor(t, block)
+	checkTiers(t, prices, 2*gwei, 6*gwei)
+}
+
+func TestPipelineSlowProvider(t *testing.T) {
+	p := newPipeline(t, false)
+	ctx := context.Background()
+	block, err := p.chain.Mine(ctx, 10, Fixed(big.NewInt(2*gwei)))
+	if err != nil {
+		t.Fatal("could not mine:", err)
+	}
+	p.waitFor(t, block)
+
+	p.provider.SetDelay(500 * time.Millisecond)
+	block, err = p.chain.Mine(ctx, 10, Fixed(big.NewInt(3*gwei)))
+	if err != nil {
+		t.Fatal("could not mine:", err)
+	}
+	// wait for the tracker to see the new head
+	time.Sleep(100 * time.Millisecond)
+	impatient := client.New(p.url, 100*time.Millisecond, 0, 0)
+	if _, err := impatient.GasPrices(ctx); err == nil {
+		t.Fatal("request should time out while the provider is slow")
+	}
+	prices := p.waitFor(t, block)
+	checkTiers(t, prices, 2*gwei, 6*gwei)
+}
@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/ArmanMazdaee/yaegpe/tracing")
+
+func start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("rpc.method", method))
+	return tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func numberAttribute(number *big.Int) attribute.KeyValue {
+	if number == nil {
+		return attribute.String("block.number", "latest")
+	}
+	return attribute.String("block.number", number.String())
+}
+
+// Provider traces the calls to a provider.
+type Provider struct {
+	provider gasprice.Provider
+}
+
+func NewProvider(provider gasprice.Provider) *Provider {
+	return &Provider{provider}
+}
+
+func (p *Provider) ChainID(ctx context.Context) (*big.Int, error) {
+	ctx, span := start(ctx, "eth_chainId")
+	chainID, err := p.provider.ChainID(ctx)
+	end(span, err)
+	return chainID, err
+}
+
+func (p *Provider) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	ctx, span := start(ctx, "eth_getBlockByNumber", numberAttribute(number))
+	header, err := p.provider.HeaderByNumber(ctx, number)
+	end(span, err)
+	return header, err
+}
+
+func (p *Provider) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	ctx, span := start(ctx, "eth_getBlockByHash", attribute.String("block.hash", hash.Hex()))
+	block, err := p.provider.BlockByHash(ctx, hash)
+	end(span, err)
+	return block, err
+}
+
+func (p *Provider) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	ctx, span := start(ctx, "eth_getBlockByNumber", numberAttribute(number))
+	block, err := p.provider.BlockByNumber(ctx, number)
+	end(span, err)
+	return block, err
+}
+
+func (p *Provider) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	ctx, span := start(ctx, "eth_subscribe")
+	sub, err := p.provider.SubscribeNewHead(ctx, ch)
+	end(span, err)
+	return sub, err
+}
+
+// Batcher traces the batches of calls to a provider.
+type Batcher struct {
+	batcher gasprice.Batcher
+}
+
+func NewBatcher(batcher gasprice.Batcher) *Batcher {
+	return &Batcher{batcher}
+}
+
+func (b *Batcher) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	ctx, span := start(ctx, "batch", attribute.Int("rpc.batch_size", len(batch)))
+	err := b.batcher.BatchCallContext(ctx, batch)
+	end(span, err)
+	return err
+}
+
+// ReceiptProvider traces the receipt calls to a provider.
+type ReceiptProvider struct {
+	receipts gasprice.ReceiptProvider
+}
+
+func NewReceiptProvider(receipts gasprice.ReceiptProvider) *ReceiptProvider {
+	return &ReceiptProvider{receipts}
+}
+
+func (r *ReceiptProvider) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	ctx, span := start(ctx, "eth_getBlockReceipts", attribute.String("block", blockNrOrHash.String()))
+	receipts, err := r.receipts.BlockReceipts(ctx, blockNrOrHash)
+	end(span, err)
+	return receipts, err
+}
+
+func (r *ReceiptProvider) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	ctx, span := start(ctx, "eth_getTransactionReceipt", attribute.String("tx.hash", txHash.Hex()))
+	receipt, err := r.receipts.TransactionReceipt(ctx, txHash)
+	end(span, err)
+	return receipt, err
+}
+
+func callAttributes(msg ethereum.CallMsg) []attribute.KeyValue {
+	if msg.To == nil {
+		return nil
+	}
+	return []attribute.KeyValue{attribute.String("call.to", msg.To.Hex())}
+}
+
+// ContractCaller traces the contract calls to a provider.
+type ContractCaller struct {
+	caller ethereum.ContractCaller
+}
+
+func NewContractCaller(caller ethereum.ContractCaller) *ContractCaller {
+	return &ContractCaller{caller}
+}
+
+func (c *ContractCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	ctx, span := start(ctx, "eth_call", append(callAttributes(msg), numberAttribute(blockNumber))...)
+	output, err := c.caller.CallContract(ctx, msg, blockNumber)
+	end(span, err)
+	return output, err
+}
+
+// GasEstimator traces the gas estimations of a provider.
+type GasEstimator struct {
+	estimator ethereum.GasEstimator
+}
+
+func NewGasEstimator(estimator ethereum.GasEstimator) *GasEstimator {
+	return &GasEstimator{estimator}
+}
+
+func (g *GasEstimator) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	ctx, span := start(ctx, "eth_estimateGas", callAttributes(msg)...)
+	gas, err := g.estimator.EstimateGas(ctx, msg)
+	end(span, err)
+	return gas, err
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ArmanMazdaee/yaegpe/gasprice"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errMock = errors.New("block not found")
+
+type providerMock struct {
+	gasprice.Provider
+}
+
+func (p providerMock) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return nil, errMock
+}
+
+func (p providerMock) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: number}, nil
+}
+
+// tracerProvider is installed once, as the tracer of the package is bound to
+// the first global provider, and each test records its spans on it.
+var tracerProvider = sdktrace.NewTracerProvider()
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	otel.SetTracerProvider(tracerProvider)
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider.RegisterSpanProcessor(recorder)
+	t.Cleanup(func() { tracerProvider.UnregisterSpanProcessor(recorder) })
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", "yaegpe"); !errors.Is(err, ErrBadExporter) {
+		t.Fatalf("expected ErrBadExporter, got %v", err)
+	}
+	shutdown, err := Setup(context.Background(), "", "yaegpe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	recorder := newRecorder(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	provider := NewProvider(providerMock{})
+	if _, err := provider.HeaderByNumber(ctx, big.NewInt(7)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.BlockByHash(ctx, common.Hash{}); !errors.Is(err, errMock) {
+		t.Fatalf("expected the error of the provider, got %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	header, block := spans[0], spans[1]
+	if header.Name() != "eth_getBlockByNumber" || header.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("unexpected span %s", header.Name())
+	}
+	if header.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("provider span should be a child of the caller span")
+	}
+	if block.Name() != "eth_getBlockByHash" || block.Status().Code != codes.Error {
+		t.Fatalf("expected a failed eth_getBlockByHash span, got %s %v", block.Name(), block.Status())
+	}
+}
+
+type callerMock struct{}
+
+func (c callerMock) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (c callerMock) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 0, errMock
+}
+
+func TestContractCallerAndGasEstimator(t *testing.T) {
+	recorder := newRecorder(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	to := common.HexToAddress("0x420000000000000000000000000000000000000F")
+	msg := ethereum.CallMsg{To: &to}
+	if _, err := NewContractCaller(callerMock{}).CallContract(ctx, msg, big.NewInt(7)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewGasEstimator(callerMock{}).EstimateGas(ctx, msg); !errors.Is(err, errMock) {
+		t.Fatalf("expected the error of the provider, got %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	call, estimate := spans[0], spans[1]
+	if call.Name() != "eth_call" || call.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("unexpected span %s", call.Name())
+	}
+	if call.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("call span should be a child of the caller span")
+	}
+	attrs := attribute.NewSet(call.Attributes()...)
+	if v, ok := attrs.Value("call.to"); !ok || v.AsString() != to.Hex() {
+		t.Fatalf("call span has no destination: %v", call.Attributes())
+	}
+	if v, ok := attrs.Value("block.number"); !ok || v.AsString() != "7" {
+		t.Fatalf("call span has no block number: %v", call.Attributes())
+	}
+	if estimate.Name() != "eth_estimateGas" || estimate.Status().Code != codes.Error {
+		t.Fatalf("expected a failed eth_estimateGas span, got %s %v", estimate.Name(), estimate.Status())
+	}
+}